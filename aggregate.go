@@ -0,0 +1,220 @@
+package rfc9457
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorsExtension lists the problems combined by Aggregate, as the "errors"
+// member.
+type ErrorsExtension struct {
+	Errors []*Response `json:"errors"`
+}
+
+func init() {
+	RegisterExtension(ErrorsExtension{})
+}
+
+// UnmarshalJSON decodes only extensions that have an "errors" member.
+func (e *ErrorsExtension) UnmarshalJSON(data []byte) error {
+	type plain ErrorsExtension
+	return unmarshalWithMember(data, "errors", (*plain)(e))
+}
+
+type AggregateArgs struct {
+	// Type, Title and Detail default to MultipleProblemsErrorType,
+	// "Multiple Problems" and "<n> problems occurred".
+	Type   ErrorTypeURI
+	Title  string
+	Detail string
+
+	Instance string
+	// Request is passed to NewResponse; see ResponseArgs.Request.
+	Request *http.Request
+
+	// Map converts errors given to AggregateErrors that neither are nor wrap
+	// a *Response. When Map is nil or returns nil, such errors become
+	// InternalServerErrorType problems without detail.
+	Map func(err error) *Response
+}
+
+// Aggregate combines problems into one whose ErrorsExtension lists them.
+// Problems that are themselves aggregates are flattened. The status is the
+// highest 5xx status among the problems or, failing that, the highest 4xx
+// status other than the generic 400, or 400. Nil problems are ignored; it
+// returns nil if none remain, and the problem itself if only one does.
+func Aggregate(args AggregateArgs, problems ...*Response) *Response {
+	var parts []*Response
+	var causes []error
+
+	for _, p := range problems {
+		parts = appendProblemParts(parts, p)
+	}
+	for _, p := range parts {
+		causes = append(causes, p)
+	}
+	return newAggregate(args, parts, errors.Join(causes...))
+}
+
+// AggregateErrors is Aggregate for arbitrary errors. Errors joined with
+// errors.Join are split into their parts; each part contributes the
+// *Response it is or wraps, or the problem args.Map returns for it. The
+// aggregate's cause is errors.Join(errs...), so errors.Is and errors.As
+// still see the original errors.
+func AggregateErrors(args AggregateArgs, errs ...error) *Response {
+	var parts []*Response
+
+	for _, err := range errs {
+		for _, e := range flattenJoined(err, nil) {
+			parts = appendProblemParts(parts, problemFor(e, args.Map))
+		}
+	}
+	return newAggregate(args, parts, errors.Join(errs...))
+}
+
+func newAggregate(args AggregateArgs, parts []*Response, cause error) (r *Response) {
+	switch len(parts) {
+	case 0:
+		goto end
+	case 1:
+		r = parts[0]
+		goto end
+	}
+	if args.Type == "" {
+		args.Type = MultipleProblemsErrorType
+	}
+	if args.Title == "" {
+		args.Title = "Multiple Problems"
+	}
+	if args.Detail == "" {
+		args.Detail = fmt.Sprintf("%d problems occurred", len(parts))
+	}
+	r = NewResponse(ResponseArgs{
+		Type:       args.Type,
+		Title:      args.Title,
+		Status:     aggregateStatus(parts),
+		Detail:     args.Detail,
+		Instance:   args.Instance,
+		Extensions: []Extension{ErrorsExtension{Errors: parts}},
+		Request:    args.Request,
+		Cause:      cause,
+	})
+end:
+	return r
+}
+
+// appendProblemParts appends p, or the parts of p if it is an aggregate.
+func appendProblemParts(parts []*Response, p *Response) []*Response {
+	if p == nil {
+		return parts
+	}
+	if split := p.Split(); len(split) > 1 || (len(split) == 1 && split[0] != p) {
+		for _, part := range split {
+			parts = appendProblemParts(parts, part)
+		}
+		return parts
+	}
+	return append(parts, p)
+}
+
+// flattenJoined appends the leaves of err's errors.Join tree to errs. A
+// *MappedError is a leaf even though it unwraps to two errors.
+func flattenJoined(err error, errs []error) []error {
+	switch joined := err.(type) {
+	case nil:
+	case *MappedError, *Response:
+		errs = append(errs, err)
+	case interface{ Unwrap() []error }:
+		for _, e := range joined.Unwrap() {
+			errs = flattenJoined(e, errs)
+		}
+	default:
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+func problemFor(err error, mapErr func(error) *Response) (p *Response) {
+	if errors.As(err, &p) {
+		goto end
+	}
+	if mapErr != nil {
+		p = mapErr(err)
+	}
+	if p == nil {
+		p = NewResponse(ResponseArgs{
+			Type:   InternalServerErrorType,
+			Title:  "Internal Server Error",
+			Status: http.StatusInternalServerError,
+			Cause:  err,
+		})
+	}
+end:
+	return p
+}
+
+func aggregateStatus(parts []*Response) int {
+	server, client := 0, 0
+	for _, p := range parts {
+		switch {
+		case p.Status >= 500 && p.Status <= 599:
+			server = max(server, p.Status)
+		case p.Status == http.StatusBadRequest:
+			client = max(client, 1)
+		case p.Status >= 401 && p.Status <= 499:
+			client = max(client, p.Status)
+		}
+	}
+	switch {
+	case server != 0:
+		return server
+	case client > 1:
+		return client
+	case client == 1:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Split returns the problems listed in r's ErrorsExtension, for example
+// those combined by Aggregate, or r alone if it has none. Pass each part to
+// MapProblemError for domain errors.
+func (r *Response) Split() []*Response {
+	for _, ext := range r.Extensions {
+		var parts []*Response
+		switch e := ext.(type) {
+		case ErrorsExtension:
+			parts = e.Errors
+		case *ErrorsExtension:
+			parts = e.Errors
+		default:
+			continue
+		}
+		for _, p := range parts {
+			if p != nil && p.httpResponse == nil {
+				p.httpResponse = r.httpResponse
+			}
+		}
+		return parts
+	}
+	return []*Response{r}
+}
+
+// redact applies p to each problem listed in e.
+func (e ErrorsExtension) redact(p RedactionProfile) (_ ErrorsExtension, redacted bool) {
+	out := ErrorsExtension{Errors: make([]*Response, len(e.Errors))}
+	for i, part := range e.Errors {
+		out.Errors[i] = part
+		if part == nil {
+			continue
+		}
+		var changed bool
+		out.Errors[i], changed = part.Redact(p)
+		redacted = redacted || changed
+	}
+	if !redacted {
+		return e, false
+	}
+	return out, true
+}
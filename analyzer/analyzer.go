@@ -0,0 +1,342 @@
+// Package analyzer defines a go/analysis Analyzer that reports misuse of
+// rfc9457.ResponseArgs and Response.Write:
+//
+//   - a ResponseArgs whose Type is a registered error type constant but whose
+//     Status differs from the registered status;
+//   - a ResponseArgs without a Title;
+//   - a call to Response.Write whose error result is discarded.
+//
+// Registered types are those predefined by the rfc9457 package plus any
+// ErrorTypeDefinition literal with constant Type and Status, such as the
+// argument of a RegisterErrorType call, in the analyzed package or the
+// packages it imports. Each report carries a suggested fix.
+//
+// The cmd/rfc9457vet command runs the analyzer standalone or as
+// go vet -vettool=$(which rfc9457vet).
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	"go/token"
+	"go/types"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const rfc9457Path = "github.com/mikeschinkel/go-rfc9457"
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "rfc9457",
+	Doc:       "report mismatched statuses and missing titles in rfc9457.ResponseArgs and ignored Response.Write errors",
+	URL:       "https://pkg.go.dev/github.com/mikeschinkel/go-rfc9457/analyzer",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(errorTypesFact)},
+}
+
+// errorType is what the analyzer knows about a registered error type.
+type errorType struct {
+	Title  string
+	Status int
+}
+
+// errorTypesFact records the error types a package defines, keyed by type
+// URI, so packages importing it can check their uses.
+type errorTypesFact struct {
+	Types map[string]errorType
+}
+
+func (*errorTypesFact) AFact() {}
+
+func (f *errorTypesFact) String() string {
+	return fmt.Sprintf("errorTypes(%d)", len(f.Types))
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	local := collectErrorTypes(pass, insp)
+	if len(local) > 0 {
+		pass.ExportPackageFact(&errorTypesFact{Types: local})
+	}
+	lookup := func(uri string) (errorType, bool) {
+		if et, ok := local[uri]; ok {
+			return et, true
+		}
+		for _, pf := range pass.AllPackageFacts() {
+			if f, ok := pf.Fact.(*errorTypesFact); ok {
+				if et, ok := f.Types[uri]; ok {
+					return et, true
+				}
+			}
+		}
+		def, ok := rfc9457.LookupErrorType(rfc9457.ErrorTypeURI(uri))
+		return errorType{Title: def.Title, Status: def.Status}, ok
+	}
+
+	filter := []ast.Node{(*ast.CompositeLit)(nil), (*ast.ExprStmt)(nil), (*ast.DeferStmt)(nil), (*ast.GoStmt)(nil)}
+	insp.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			if isNamed(pass.TypesInfo.TypeOf(n), "ResponseArgs") {
+				checkResponseArgs(pass, n, lookup)
+			}
+		case *ast.ExprStmt:
+			checkWriteCall(pass, n, n.X, fileOf(stack))
+		case *ast.DeferStmt:
+			checkWriteCall(pass, n, n.Call, nil)
+		case *ast.GoStmt:
+			checkWriteCall(pass, n, n.Call, nil)
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// collectErrorTypes gathers ErrorTypeDefinition literals whose Type and
+// Status are constants.
+func collectErrorTypes(pass *analysis.Pass, insp *inspector.Inspector) map[string]errorType {
+	types := make(map[string]errorType)
+	insp.Preorder([]ast.Node{(*ast.CompositeLit)(nil)}, func(n ast.Node) {
+		lit := n.(*ast.CompositeLit)
+		if !isNamed(pass.TypesInfo.TypeOf(lit), "ErrorTypeDefinition") {
+			return
+		}
+		fields := keyedFields(lit)
+		uri, ok := constString(pass, fields["Type"])
+		if !ok {
+			return
+		}
+		status, ok := constInt(pass, fields["Status"])
+		if !ok {
+			return
+		}
+		title, _ := constString(pass, fields["Title"])
+		types[uri] = errorType{Title: title, Status: status}
+	})
+	return types
+}
+
+func checkResponseArgs(pass *analysis.Pass, lit *ast.CompositeLit, lookup func(string) (errorType, bool)) {
+	fields := keyedFields(lit)
+	if len(lit.Elts) > 0 && len(fields) == 0 {
+		// Positional literals are rare enough not to bother with
+		return
+	}
+
+	var et errorType
+	var known bool
+	uri, isConst := constString(pass, fields["Type"])
+	if isConst {
+		et, known = lookup(uri)
+	}
+	status, statusConst := constInt(pass, fields["Status"])
+
+	if known && et.Status != 0 && statusConst && status != et.Status {
+		statusExpr := fields["Status"]
+		pass.Report(analysis.Diagnostic{
+			Pos:     statusExpr.Pos(),
+			End:     statusExpr.End(),
+			Message: fmt.Sprintf("status %d does not match status %d registered for %s", status, et.Status, typeName(fields["Type"])),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message: fmt.Sprintf("Use status %d", et.Status),
+				TextEdits: []analysis.TextEdit{{
+					Pos:     statusExpr.Pos(),
+					End:     statusExpr.End(),
+					NewText: []byte(strconv.Itoa(et.Status)),
+				}},
+			}},
+		})
+	}
+
+	titleExpr, hasTitle := fields["Title"]
+	if title, ok := constString(pass, titleExpr); hasTitle && (!ok || title != "") {
+		return
+	}
+	diag := analysis.Diagnostic{
+		Pos:     lit.Pos(),
+		End:     lit.End(),
+		Message: "ResponseArgs has no Title",
+	}
+	title := et.Title
+	if title == "" && statusConst {
+		title = http.StatusText(status)
+	}
+	if title != "" {
+		diag.SuggestedFixes = []analysis.SuggestedFix{titleFix(pass, lit, titleExpr, title)}
+	}
+	pass.Report(diag)
+}
+
+// titleFix sets the Title of lit, replacing an empty one or adding the field
+// after Type.
+func titleFix(pass *analysis.Pass, lit *ast.CompositeLit, titleExpr ast.Expr, title string) analysis.SuggestedFix {
+	quoted := []byte(strconv.Quote(title))
+	fix := analysis.SuggestedFix{Message: fmt.Sprintf("Set Title to %q", title)}
+	if titleExpr != nil {
+		fix.TextEdits = []analysis.TextEdit{{Pos: titleExpr.Pos(), End: titleExpr.End(), NewText: quoted}}
+		return fix
+	}
+
+	var after ast.Node
+	for _, elt := range lit.Elts {
+		kv := elt.(*ast.KeyValueExpr)
+		if id, ok := kv.Key.(*ast.Ident); ok && id.Name == "Type" {
+			after = kv
+		}
+	}
+	var edit analysis.TextEdit
+	multiline := pass.Fset.Position(lit.Lbrace).Line != pass.Fset.Position(lit.Rbrace).Line
+	switch {
+	case after != nil && multiline:
+		// Skip the trailing comma and match the indentation of Type
+		indent := strings.Repeat("\t", pass.Fset.Position(after.Pos()).Column-1)
+		text := fmt.Sprintf("\n%sTitle: %s,", indent, quoted)
+		edit = analysis.TextEdit{Pos: after.End() + 1, End: after.End() + 1, NewText: []byte(text)}
+	case after != nil:
+		edit = analysis.TextEdit{Pos: after.End(), End: after.End(), NewText: append([]byte(", Title: "), quoted...)}
+	case len(lit.Elts) > 0:
+		edit = analysis.TextEdit{Pos: lit.Elts[0].Pos(), End: lit.Elts[0].Pos(), NewText: append(append([]byte("Title: "), quoted...), ", "...)}
+	default:
+		edit = analysis.TextEdit{Pos: lit.Rbrace, End: lit.Rbrace, NewText: append([]byte("Title: "), quoted...)}
+	}
+	fix.TextEdits = []analysis.TextEdit{edit}
+	return fix
+}
+
+// checkWriteCall reports expr if it is a call to Response.Write whose result
+// is discarded by stmt. A fix wrapping the call in an error check is offered
+// for plain statements in file.
+func checkWriteCall(pass *analysis.Pass, stmt ast.Stmt, expr ast.Expr, file *ast.File) {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || !isResponseWrite(pass, call) {
+		return
+	}
+	diag := analysis.Diagnostic{
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: "error returned by Response.Write is not checked",
+	}
+	if file != nil {
+		var buf bytes.Buffer
+		_ = format.Node(&buf, pass.Fset, call)
+		logger := "Logger()"
+		if pkg := importName(file, rfc9457Path); pkg != "" {
+			logger = pkg + ".Logger()"
+		}
+		indent := strings.Repeat("\t", pass.Fset.Position(stmt.Pos()).Column-1)
+		fix := fmt.Sprintf("if writeErr := %s; writeErr != nil {\n%s\t%s.Error(\"Failed to write problem response\", \"error\", writeErr)\n%s}",
+			buf.String(), indent, logger, indent)
+		diag.SuggestedFixes = []analysis.SuggestedFix{{
+			Message:   "Check the error",
+			TextEdits: []analysis.TextEdit{{Pos: stmt.Pos(), End: stmt.End(), NewText: []byte(fix)}},
+		}}
+	}
+	pass.Report(diag)
+}
+
+func isResponseWrite(pass *analysis.Pass, call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Name() != "Write" {
+		return false
+	}
+	recv := fn.Signature().Recv()
+	return recv != nil && isNamed(recv.Type(), "Response")
+}
+
+// isNamed reports whether t, or what it points to, is the named type from
+// the rfc9457 package.
+func isNamed(t types.Type, name string) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == rfc9457Path
+}
+
+func keyedFields(lit *ast.CompositeLit) map[string]ast.Expr {
+	fields := make(map[string]ast.Expr)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if id, ok := kv.Key.(*ast.Ident); ok {
+			fields[id.Name] = kv.Value
+		}
+	}
+	return fields
+}
+
+func constString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	if expr == nil {
+		return "", false
+	}
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+func constInt(pass *analysis.Pass, expr ast.Expr) (int, bool) {
+	if expr == nil {
+		return 0, false
+	}
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	i, exact := constant.Int64Val(tv.Value)
+	return int(i), exact
+}
+
+// typeName renders the Type expression for messages.
+func typeName(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+func fileOf(stack []ast.Node) *ast.File {
+	if len(stack) == 0 {
+		return nil
+	}
+	file, _ := stack[0].(*ast.File)
+	return file
+}
+
+// importName returns the name path is imported as in file, or "" if file is
+// in that package or does not import it.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if value, _ := strconv.Unquote(spec.Path.Value); value == path {
+			if spec.Name != nil {
+				return spec.Name.Name
+			}
+			return "rfc9457"
+		}
+	}
+	return ""
+}
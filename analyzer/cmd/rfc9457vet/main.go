@@ -0,0 +1,16 @@
+// Command rfc9457vet runs the rfc9457 analyzer. It can be run directly on
+// packages, or by go vet:
+//
+//	go vet -vettool=$(which rfc9457vet) ./...
+//
+// Pass -fix to apply the suggested fixes.
+package main
+
+import (
+	"github.com/mikeschinkel/go-rfc9457/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
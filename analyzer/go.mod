@@ -0,0 +1,13 @@
+module github.com/mikeschinkel/go-rfc9457/analyzer
+
+go 1.25.3
+
+replace github.com/mikeschinkel/go-rfc9457 => ../
+
+require github.com/mikeschinkel/go-rfc9457 v0.0.0-00010101000000-000000000000
+
+require (
+	golang.org/x/mod v0.36.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/tools v0.45.0
+)
@@ -0,0 +1,13 @@
+package errtypes
+
+import "github.com/mikeschinkel/go-rfc9457"
+
+const QuotaExceeded rfc9457.ErrorTypeURI = "https://example.com/errors/quota-exceeded"
+
+func init() {
+	rfc9457.RegisterErrorType(rfc9457.ErrorTypeDefinition{
+		Type:   QuotaExceeded,
+		Title:  "Quota Exceeded",
+		Status: 429,
+	})
+}
@@ -0,0 +1,47 @@
+// Package rfc9457 is a stub of the real package with just enough API for the
+// analyzer tests.
+package rfc9457
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+type ErrorTypeURI string
+
+const (
+	NoResultsErrorType        ErrorTypeURI = "https://schema.xmlui.org/errors/test-server/api/database/no-results"
+	InvalidParameterErrorType ErrorTypeURI = "https://schema.xmlui.org/errors/test-server/api/validation/invalid-parameter-type"
+)
+
+type Extension interface{}
+
+type ErrorTypeDefinition struct {
+	Type   ErrorTypeURI
+	Title  string
+	Status int
+}
+
+func RegisterErrorType(def ErrorTypeDefinition) {}
+
+type ResponseArgs struct {
+	Type       ErrorTypeURI
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions []Extension
+	Request    *http.Request
+}
+
+type Response struct {
+	Type   ErrorTypeURI
+	Title  string
+	Status int
+}
+
+func NewResponse(args ResponseArgs) *Response { return &Response{} }
+
+func (r *Response) Write(w http.ResponseWriter) error { return nil }
+
+func Logger() *slog.Logger { return slog.Default() }
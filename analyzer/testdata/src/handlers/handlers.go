@@ -0,0 +1,53 @@
+package handlers // want package:"errorTypes\\(1\\)"
+
+import (
+	"net/http"
+
+	"errtypes"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+const localConflict rfc9457.ErrorTypeURI = "https://example.com/errors/local-conflict"
+
+var defs = []rfc9457.ErrorTypeDefinition{
+	{Type: localConflict, Title: "Local Conflict", Status: 409},
+}
+
+func predefined(w http.ResponseWriter) {
+	resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:   rfc9457.NoResultsErrorType,
+		Title:  "No Results",
+		Status: 500, // want `status 500 does not match status 404 registered for rfc9457.NoResultsErrorType`
+	})
+	_ = resp.Write(w)
+}
+
+func imported(w http.ResponseWriter) error {
+	resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:   errtypes.QuotaExceeded,
+		Title:  "Quota Exceeded",
+		Status: http.StatusServiceUnavailable, // want `status 503 does not match status 429 registered for errtypes.QuotaExceeded`
+	})
+	return resp.Write(w)
+}
+
+func missingTitle(w http.ResponseWriter) {
+	resp := rfc9457.NewResponse(rfc9457.ResponseArgs{ // want `ResponseArgs has no Title`
+		Type:   localConflict,
+		Status: 409,
+	})
+	resp.Write(w) // want `error returned by Response.Write is not checked`
+}
+
+func emptyTitle(w http.ResponseWriter) {
+	defer rfc9457.NewResponse(rfc9457.ResponseArgs{Title: "", Status: 404}).Write(w) // want `ResponseArgs has no Title` `error returned by Response.Write is not checked`
+}
+
+func unknownType(w http.ResponseWriter, title string) error {
+	return rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:   "https://example.com/errors/unregistered",
+		Title:  title,
+		Status: 400,
+	}).Write(w)
+}
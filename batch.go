@@ -0,0 +1,252 @@
+package rfc9457
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+)
+
+var _ ResponsePayload = (*BatchResult)(nil)
+
+var ErrNotBatch = errors.New("response is not a batch result")
+
+// DefaultMaxBatchBodySize is the body size limit used by ParseBatchResult.
+const DefaultMaxBatchBodySize int64 = 32 << 20
+
+// BatchItem is the outcome for one item of a batch request: a Result on
+// success or a Problem on failure. Status is the item's own HTTP status.
+type BatchItem struct {
+	ID      string    `json:"id"`
+	Status  int       `json:"status"`
+	Result  any       `json:"result,omitempty"`
+	Problem *Response `json:"problem,omitempty"`
+}
+
+// Err returns nil if the item succeeded, otherwise a *BatchItemError
+// wrapping MapProblemError(item.Problem).
+func (item BatchItem) Err() error {
+	if item.Problem == nil {
+		return nil
+	}
+	return &BatchItemError{ID: item.ID, Err: MapProblemError(item.Problem)}
+}
+
+// DecodeResult decodes the item's Result into v. On the client Result holds
+// the raw JSON of the result.
+func (item BatchItem) DecodeResult(v any) (err error) {
+	var data []byte
+
+	if item.Result == nil {
+		err = fmt.Errorf("batch item %s has no result", item.ID)
+		goto end
+	}
+	data, err = json.Marshal(item.Result)
+	if err != nil {
+		goto end
+	}
+	err = json.Unmarshal(data, v)
+end:
+	return err
+}
+
+// BatchItemError is the error for a failed batch item.
+type BatchItemError struct {
+	ID  string
+	Err error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("batch item %s: %v", e.ID, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchResult is the response to a batch request, sent as an
+// application/json envelope:
+//
+//	{"items": [
+//	  {"id": "1", "status": 201, "result": {...}},
+//	  {"id": "2", "status": 404, "problem": {...}}
+//	]}
+//
+// with status 200 if every item succeeded and 207 Multi-Status otherwise.
+type BatchResult struct {
+	Items []BatchItem `json:"items"`
+
+	httpResponse *http.Response
+}
+
+// Succeed records a successful item with status 200.
+func (b *BatchResult) Succeed(id string, result any) {
+	b.Items = append(b.Items, BatchItem{ID: id, Status: http.StatusOK, Result: result})
+}
+
+// Fail records a failed item with problem's status, or 500 if that is not a
+// problem status, in which case Write sends the problem with status 500 as
+// well. A nil problem is recorded as a 500 about:blank problem.
+func (b *BatchResult) Fail(id string, problem *Response) {
+	if problem == nil {
+		problem = NewResponse(ResponseArgs{
+			Type:   AboutBlankErrorType,
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+		})
+	}
+	status := problem.Status
+	if !IsProblemStatus(status) {
+		status = http.StatusInternalServerError
+	}
+	b.Items = append(b.Items, BatchItem{ID: id, Status: status, Problem: problem})
+}
+
+// Failed returns the items that have a Problem.
+func (b *BatchResult) Failed() (items []BatchItem) {
+	for _, item := range b.Items {
+		if item.Problem != nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Err returns the *BatchItemError of each failed item joined with
+// errors.Join, or nil if every item succeeded.
+func (b *BatchResult) Err() error {
+	var errs []error
+	for _, item := range b.Failed() {
+		errs = append(errs, item.Err())
+	}
+	return errors.Join(errs...)
+}
+
+func (b *BatchResult) Error() string {
+	return fmt.Sprintf("%d of %d batch items failed", len(b.Failed()), len(b.Items))
+}
+
+func (b *BatchResult) MIMEType() MIMEType {
+	return ApplicationJSON
+}
+
+func (b *BatchResult) HTTPStatusCode() int {
+	if len(b.Failed()) == 0 {
+		return http.StatusOK
+	}
+	return http.StatusMultiStatus
+}
+
+func (*BatchResult) ResponsePayload() {}
+
+// HTTPResponse returns the response b was parsed from by ParseBatchResult,
+// or nil.
+func (b *BatchResult) HTTPResponse() *http.Response {
+	return b.httpResponse
+}
+
+// Write sends b as a 200 or 207 envelope. Item problems are sent, logged
+// and recorded as Response.Write would, without localization: with their
+// item's status and the RedactionProfile and Scrubber applied. Once the
+// envelope is written, observers are notified of each; elapsed is the time
+// taken to write the whole envelope. It returns a *WriteError without
+// writing if w is a CommitTracker that has already committed.
+func (b *BatchResult) Write(w http.ResponseWriter) (err error) {
+	var out BatchResult
+	var logged []*Response
+	start := time.Now()
+	status := b.HTTPStatusCode()
+
+	if ct, ok := w.(CommitTracker); ok && ct.Committed() {
+		err = &WriteError{Err: ErrResponseCommitted, Status: status, CommittedStatus: ct.Status()}
+		goto end
+	}
+	out.Items = make([]BatchItem, len(b.Items))
+	logged = make([]*Response, len(b.Items))
+	for i, item := range b.Items {
+		if item.Problem != nil {
+			item.Problem, logged[i] = item.Problem.prepareWrite(item.Problem, item.Status)
+		}
+		out.Items[i] = item
+	}
+	w.Header().Set("Content-Type", string(ApplicationJSON))
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(out)
+	if err != nil {
+		goto end
+	}
+	for i, item := range b.Items {
+		if item.Problem != nil {
+			item.Problem.written(logged[i], item.Status, time.Since(start))
+		}
+	}
+end:
+	return err
+}
+
+// ParseBatchResult decodes the batch envelope in resp using
+// DefaultMaxBatchBodySize. See ParseBatchResultLimited.
+func ParseBatchResult(resp *http.Response) (*BatchResult, error) {
+	return ParseBatchResultLimited(resp, DefaultMaxBatchBodySize)
+}
+
+// ParseBatchResultLimited decodes the batch envelope in resp, reading at
+// most maxBodySize bytes of body. Each item's Result is kept as raw JSON for
+// BatchItem.DecodeResult. If the whole request failed, the error is the
+// problem decoded by ParseHTTPResponse.
+//
+// Like ParseHTTPResponseLimited, it replaces the body with an in-memory copy.
+func ParseBatchResultLimited(resp *http.Response, maxBodySize int64) (b *BatchResult, err error) {
+	var body []byte
+	var mediaType string
+	var problem *Response
+	var envelope struct {
+		Items []struct {
+			ID      string          `json:"id"`
+			Status  int             `json:"status"`
+			Result  json.RawMessage `json:"result"`
+			Problem *Response       `json:"problem"`
+		} `json:"items"`
+	}
+
+	if resp.StatusCode >= 400 {
+		problem, err = ParseHTTPResponseLimited(resp, maxBodySize)
+		if err == nil {
+			err = problem
+		}
+		goto end
+	}
+	body, err = readLimitedBody(resp, maxBodySize)
+	if err != nil {
+		goto end
+	}
+	mediaType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !isJSONMediaType(mediaType) {
+		err = fmt.Errorf("%w: %s with status %d", ErrNotBatch, resp.Header.Get("Content-Type"), resp.StatusCode)
+		goto end
+	}
+	err = json.Unmarshal(body, &envelope)
+	if err != nil {
+		err = fmt.Errorf("decoding batch response: %w", err)
+		goto end
+	}
+	b = &BatchResult{
+		Items:        make([]BatchItem, len(envelope.Items)),
+		httpResponse: resp,
+	}
+	for i, item := range envelope.Items {
+		b.Items[i] = BatchItem{ID: item.ID, Status: item.Status}
+		if item.Result != nil {
+			b.Items[i].Result = item.Result
+		}
+		if item.Problem != nil {
+			item.Problem.httpResponse = resp
+			b.Items[i].Problem = item.Problem
+			notifyDecoded(item.Problem)
+		}
+	}
+end:
+	return b, err
+}
@@ -0,0 +1,107 @@
+package rfc9457
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Placeholders substituted for values that differ on every run.
+const (
+	UUIDPlaceholder      = "<uuid>"
+	ULIDPlaceholder      = "<ulid>"
+	TimestampPlaceholder = "<timestamp>"
+)
+
+var (
+	uuidPattern      = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	ulidPattern      = regexp.MustCompile(`\b[0-7][0-9A-HJKMNP-TV-Z]{25}\b`)
+	timestampPattern = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})`)
+)
+
+// Canonicalizer renders problem documents in a stable form: keys sorted,
+// two-space indentation and a trailing newline. Unless told to keep them,
+// UUIDs and ULIDs (as used in occurrence instances) and RFC 3339 timestamps
+// in string values are replaced by placeholders. The zero value is ready to
+// use.
+type Canonicalizer struct {
+	KeepIDs        bool
+	KeepTimestamps bool
+}
+
+// Canonicalize renders r with the zero Canonicalizer.
+func Canonicalize(r *Response) ([]byte, error) {
+	return Canonicalizer{}.Response(r)
+}
+
+// CanonicalizeJSON renders a JSON document with the zero Canonicalizer.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	return Canonicalizer{}.JSON(data)
+}
+
+// Response renders r canonically.
+func (c Canonicalizer) Response(r *Response) ([]byte, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.JSON(data)
+}
+
+// JSON renders any JSON document canonically, such as a recorded body.
+func (c Canonicalizer) JSON(data []byte) (out []byte, err error) {
+	var doc any
+	var buf bytes.Buffer
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&doc)
+	if err != nil {
+		err = fmt.Errorf("canonicalizing JSON: %w", err)
+		goto end
+	}
+	if dec.More() {
+		err = fmt.Errorf("canonicalizing JSON: unexpected data after document")
+		goto end
+	}
+	doc = c.normalize(doc)
+
+	// encoding/json writes map keys in sorted order
+	{
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(doc)
+	}
+	out = buf.Bytes()
+end:
+	return out, err
+}
+
+func (c Canonicalizer) normalize(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			t[key] = c.normalize(value)
+		}
+	case []any:
+		for i, value := range t {
+			t[i] = c.normalize(value)
+		}
+	case string:
+		return c.normalizeString(t)
+	}
+	return v
+}
+
+func (c Canonicalizer) normalizeString(s string) string {
+	if !c.KeepTimestamps {
+		s = timestampPattern.ReplaceAllString(s, TimestampPlaceholder)
+	}
+	if !c.KeepIDs {
+		s = uuidPattern.ReplaceAllString(s, UUIDPlaceholder)
+		s = ulidPattern.ReplaceAllString(s, ULIDPlaceholder)
+	}
+	return s
+}
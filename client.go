@@ -0,0 +1,178 @@
+package rfc9457
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxProblemBodySize is the body size limit used by ParseHTTPResponse.
+const DefaultMaxProblemBodySize int64 = 1 << 20
+
+// bodyExcerptSize bounds how much of a non-problem body is copied into the
+// Detail of a synthesized problem.
+const bodyExcerptSize = 256
+
+var (
+	ErrProblemBodyTooLarge = errors.New("problem body exceeds size limit")
+	ErrNotProblem          = errors.New("response is not a problem")
+)
+
+// ParseHTTPResponse decodes the problem document in resp using
+// DefaultMaxProblemBodySize. See ParseHTTPResponseLimited.
+func ParseHTTPResponse(resp *http.Response) (*Response, error) {
+	return ParseHTTPResponseLimited(resp, DefaultMaxProblemBodySize)
+}
+
+// ParseHTTPResponseLimited decodes the problem document in resp, reading at
+// most maxBodySize bytes of body. application/problem+json and
+// application/problem+xml bodies are decoded as problems, as are JSON bodies
+// shaped like one. Any other error response yields a synthesized about:blank
+// problem whose Detail is an excerpt of the body; a non-error response that
+// is not a problem yields ErrNotProblem.
+//
+// The body is consumed and closed, then replaced with an in-memory copy so
+// callers can still read it.
+func ParseHTTPResponseLimited(resp *http.Response, maxBodySize int64) (r *Response, err error) {
+	var body []byte
+	var mediaType string
+
+	body, err = readLimitedBody(resp, maxBodySize)
+	if err != nil {
+		goto end
+	}
+	mediaType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	r = &Response{}
+	switch {
+	case mediaType == string(ApplicationProblemJSON):
+		err = r.UnmarshalJSON(body)
+	case mediaType == string(ApplicationProblemXML):
+		err = UnmarshalProblemXML(body, r)
+	case isJSONMediaType(mediaType) && hasProblemShape(body):
+		err = r.UnmarshalJSON(body)
+	case resp.StatusCode >= 400:
+		r = synthesizeProblem(resp.StatusCode, mediaType, body)
+		goto end
+	default:
+		r = nil
+		err = fmt.Errorf("%w: %s with status %d", ErrNotProblem, resp.Header.Get("Content-Type"), resp.StatusCode)
+		goto end
+	}
+	if err != nil {
+		notifyObservers(func(o Observer) { o.ProblemDecodeFailed(err) })
+		r = nil
+		err = fmt.Errorf("decoding %s body: %w", mediaType, err)
+		goto end
+	}
+	if r.Type == "" {
+		r.Type = AboutBlankErrorType
+	}
+	if r.Status == 0 {
+		r.Status = resp.StatusCode
+	}
+	notifyDecoded(r)
+end:
+	if r != nil {
+		r.httpResponse = resp
+	}
+	return r, err
+}
+
+// HTTPResponse returns the response r was parsed from by ParseHTTPResponse
+// or Transport, or nil. Its body has already been read but can be read again.
+func (r *Response) HTTPResponse() *http.Response {
+	return r.httpResponse
+}
+
+func readLimitedBody(resp *http.Response, maxBodySize int64) (body []byte, err error) {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		goto end
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		err = fmt.Errorf("reading problem body: %w", err)
+		goto end
+	}
+	if int64(len(body)) > maxBodySize {
+		err = fmt.Errorf("%w of %d bytes", ErrProblemBodyTooLarge, maxBodySize)
+	}
+end:
+	return body, err
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == string(ApplicationJSON) || strings.HasSuffix(mediaType, "+json")
+}
+
+// hasProblemShape reports whether body is a JSON object that has at least one
+// of the standard problem members and whose standard members have the types
+// RFC 9457 requires.
+func hasProblemShape(body []byte) bool {
+	var members map[string]any
+	if json.Unmarshal(body, &members) != nil {
+		return false
+	}
+	found := false
+	for name, value := range members {
+		switch name {
+		case "type", "title", "detail", "instance":
+			if _, ok := value.(string); !ok {
+				return false
+			}
+			found = true
+		case "status":
+			if _, ok := value.(float64); !ok {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// synthesizeProblem builds an about:blank problem for an error response
+// whose body is not a problem document.
+func synthesizeProblem(status int, mediaType string, body []byte) *Response {
+	r := &Response{
+		Type:   AboutBlankErrorType,
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if isTextMediaType(mediaType) || (mediaType == "" && utf8.Valid(body)) {
+		r.Detail = bodyExcerpt(body)
+	}
+	return r
+}
+
+func isTextMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		isJSONMediaType(mediaType) ||
+		mediaType == "application/xml" ||
+		strings.HasSuffix(mediaType, "+xml")
+}
+
+// bodyExcerpt returns up to bodyExcerptSize bytes of body as a single line,
+// cut on a rune boundary.
+func bodyExcerpt(body []byte) string {
+	truncated := len(body) > bodyExcerptSize
+	if truncated {
+		body = body[:bodyExcerptSize]
+		for len(body) > 0 && !utf8.Valid(body) {
+			body = body[:len(body)-1]
+		}
+	}
+	excerpt := strings.Join(strings.Fields(string(body)), " ")
+	if truncated {
+		excerpt += "…"
+	}
+	return excerpt
+}
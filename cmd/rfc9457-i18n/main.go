@@ -0,0 +1,175 @@
+// Command rfc9457-i18n manages translations of problem titles and details.
+//
+//	rfc9457-i18n extract [-format po|xliff] [-lang xx] [-o file] [dir...]
+//	rfc9457-i18n lint -template file translation...
+//
+// extract writes a template containing the canonical titles of the
+// predefined error types plus every title and catalog message found in the
+// Go packages in the given directories. With -lang it writes an empty
+// translation for that language instead of a template.
+//
+// lint compares PO or XLIFF translations against a template and exits
+// non-zero if any are missing or stale.
+//
+// Translated files are loaded at runtime with rfc9457.LoadCatalogFS, usually
+// from an embed.FS.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/internal/msgextract"
+)
+
+// errIssuesFound signals a lint failure that has already been reported.
+var errIssuesFound = errors.New("translation issues found")
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case errors.Is(err, errIssuesFound):
+		os.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, "rfc9457-i18n: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: rfc9457-i18n extract|lint [flags] [args]")
+	}
+	switch args[0] {
+	case "extract":
+		return runExtract(args[1:], stdout, stderr)
+	case "lint":
+		return runLint(args[1:], stdout)
+	}
+	return fmt.Errorf("unknown command %q; expected extract or lint", args[0])
+}
+
+func runExtract(args []string, stdout, stderr io.Writer) (err error) {
+	var units []rfc9457.TranslationUnit
+	var warnings []error
+	var tf rfc9457.TranslationFile
+	var w io.Writer
+
+	fs := flag.NewFlagSet("extract", flag.ContinueOnError)
+	format := fs.String("format", string(rfc9457.POFormat), "output format: po or xliff")
+	lang := fs.String("lang", "", "write an untranslated file for this language instead of a template")
+	out := fs.String("o", "", "output file (default stdout)")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+
+	units, warnings, err = msgextract.Extract(fs.Args())
+	if err != nil {
+		goto end
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(stderr, "warning: %v\n", warning)
+	}
+	tf = rfc9457.TranslationFile{
+		Language: *lang,
+		Units:    mergeUnits(rfc9457.TemplateUnits(nil), units),
+	}
+
+	w = stdout
+	if *out != "" {
+		var f *os.File
+		f, err = os.Create(*out)
+		if err != nil {
+			goto end
+		}
+		defer func() {
+			closeErr := f.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}()
+		w = f
+	}
+	err = rfc9457.WriteTranslationFile(w, tf, rfc9457.TranslationFormat(*format))
+end:
+	return err
+}
+
+// mergeUnits combines unit lists, later lists winning on duplicate keys.
+func mergeUnits(lists ...[]rfc9457.TranslationUnit) []rfc9457.TranslationUnit {
+	byKey := make(map[rfc9457.MessageKey]rfc9457.TranslationUnit)
+	for _, list := range lists {
+		for _, unit := range list {
+			byKey[unit.Key] = unit
+		}
+	}
+	merged := make([]rfc9457.TranslationUnit, 0, len(byKey))
+	for _, unit := range byKey {
+		merged = append(merged, unit)
+	}
+	return rfc9457.SortTranslationUnits(merged)
+}
+
+func runLint(args []string, stdout io.Writer) (err error) {
+	var template rfc9457.TranslationFile
+	var failed bool
+
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	templatePath := fs.String("template", "", "template (.pot, .po, .xlf or .xliff) to compare against")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+	if *templatePath == "" || fs.NArg() == 0 {
+		err = fmt.Errorf("usage: rfc9457-i18n lint -template file translation...")
+		goto end
+	}
+
+	template, err = readTranslationFile(*templatePath)
+	if err != nil {
+		goto end
+	}
+	for _, path := range fs.Args() {
+		var translation rfc9457.TranslationFile
+		translation, err = readTranslationFile(path)
+		if err != nil {
+			goto end
+		}
+		for _, issue := range rfc9457.LintTranslations(template, translation) {
+			fmt.Fprintf(stdout, "%s: %s\n", path, issue)
+			failed = true
+		}
+	}
+	if failed {
+		err = errIssuesFound
+	}
+end:
+	return err
+}
+
+func readTranslationFile(path string) (tf rfc9457.TranslationFile, err error) {
+	var format rfc9457.TranslationFormat
+	var f *os.File
+
+	format, err = rfc9457.TranslationFormatFor(path)
+	if err != nil {
+		goto end
+	}
+	f, err = os.Open(path)
+	if err != nil {
+		goto end
+	}
+	defer func() { _ = f.Close() }()
+	tf, err = rfc9457.ReadTranslationFile(f, format)
+	if err != nil {
+		err = fmt.Errorf("reading %s: %w", path, err)
+	}
+end:
+	return tf, err
+}
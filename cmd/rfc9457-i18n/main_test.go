@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_ExtractAndLint(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "messages.pot")
+	fr := filepath.Join(dir, "fr.po")
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"extract", "-o", template, "testdata/app"}, &stdout, &stderr); err != nil {
+		t.Fatalf("extract: %v\n%s", err, stderr.String())
+	}
+	data, err := os.ReadFile(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`|no-user"`, `msgid "No user {id}"`, `msgid "No Results"`} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("Template lacks %s:\n%s", want, data)
+		}
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("extract warnings: %s", stderr.String())
+	}
+
+	if err := run([]string{"extract", "-lang", "fr", "-o", fr, "testdata/app"}, &stdout, &stderr); err != nil {
+		t.Fatalf("extract -lang: %v", err)
+	}
+	stdout.Reset()
+	err = run([]string{"lint", "-template", template, fr}, &stdout, &stderr)
+	if !errors.Is(err, errIssuesFound) {
+		t.Errorf("lint of an untranslated file: got %v, want errIssuesFound", err)
+	}
+	if !strings.Contains(stdout.String(), "[no-user]: not translated") {
+		t.Errorf("lint output:\n%s", stdout.String())
+	}
+
+	// Translate every entry after the header
+	data, err = os.ReadFile(fr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, entries, _ := strings.Cut(string(data), "\n\n")
+	entries = strings.ReplaceAll(entries, "msgstr \"\"", "msgstr \"traduit\"")
+	if err := os.WriteFile(fr, []byte(header+"\n\n"+entries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if err := run([]string{"lint", "-template", template, fr}, &stdout, &stderr); err != nil {
+		t.Errorf("lint of a complete translation: %v\n%s", err, stdout.String())
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{nil, {"translate"}, {"lint"}} {
+		if err := run(args, &bytes.Buffer{}, &bytes.Buffer{}); err == nil || errors.Is(err, errIssuesFound) {
+			t.Errorf("run(%q): got %v, want a usage error", args, err)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package app
+
+import "github.com/mikeschinkel/go-rfc9457"
+
+func noUser() *rfc9457.Response {
+	return rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:      rfc9457.NoResultsErrorType,
+		Title:     "No Results",
+		Status:    404,
+		MessageID: "no-user",
+		Detail:    "No user {id}",
+	})
+}
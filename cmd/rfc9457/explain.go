@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// explain writes a human-readable account of a problem, including what the
+// registry knows about its type and any violations.
+func explain(w io.Writer, doc document) {
+	r := doc.problem
+	title := r.Title
+	if title == "" {
+		title = http.StatusText(r.Status)
+	}
+	fmt.Fprintf(w, "%d %s\n", r.Status, title)
+
+	typ := r.Type
+	if typ == "" {
+		typ = rfc9457.AboutBlankErrorType
+	}
+	fmt.Fprintf(w, "  Type:      %s\n", typ)
+	if def, ok := rfc9457.LookupErrorType(typ); ok {
+		fmt.Fprintf(w, "             registered as %q, status %d\n", def.Title, def.Status)
+	} else if typ == rfc9457.AboutBlankErrorType {
+		fmt.Fprintf(w, "             no further semantics beyond the status code\n")
+	} else {
+		fmt.Fprintf(w, "             not a registered error type\n")
+	}
+	if r.Detail != "" {
+		fmt.Fprintf(w, "  Detail:    %s\n", r.Detail)
+	}
+	if r.Instance != "" {
+		fmt.Fprintf(w, "  Instance:  %s\n", r.Instance)
+	}
+	retryable := "no"
+	if rfc9457.IsRetryable(r) {
+		retryable = "yes"
+	}
+	fmt.Fprintf(w, "  Retryable: %s\n", retryable)
+	for i, ext := range r.Extensions {
+		data, err := json.Marshal(ext)
+		if err != nil {
+			data = []byte(fmt.Sprintf("<unencodable %T>", ext))
+		}
+		fmt.Fprintf(w, "  Extension %d: %s\n", i, data)
+	}
+	for _, v := range doc.violations {
+		fmt.Fprintf(w, "  Violation: %s\n", v)
+	}
+}
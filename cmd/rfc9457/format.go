@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/internal/cbor"
+)
+
+// Format names a problem document encoding.
+type Format string
+
+const (
+	JSONFormat Format = "json"
+	XMLFormat  Format = "xml"
+	CBORFormat Format = "cbor"
+)
+
+const stdinName = "-"
+
+// document is a decoded problem with the violations found while decoding.
+// For JSON and CBOR input, json holds the whole document as JSON, including
+// the top-level extension members that problem does not keep.
+type document struct {
+	format     Format
+	problem    *rfc9457.Response
+	violations []rfc9457.Violation
+	json       []byte
+}
+
+func readDocument(name string, format Format, stdin io.Reader) (doc document, err error) {
+	var data []byte
+
+	if name == stdinName {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		goto end
+	}
+	if format == "" {
+		format = detectFormat(name, data)
+	}
+	doc, err = decodeDocument(data, format)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", name, err)
+	}
+end:
+	return doc, err
+}
+
+// detectFormat infers the format from the file extension, then from the
+// first significant byte.
+func detectFormat(name string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return JSONFormat
+	case ".xml":
+		return XMLFormat
+	case ".cbor":
+		return CBORFormat
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return JSONFormat
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return XMLFormat
+	}
+	return CBORFormat
+}
+
+func decodeDocument(data []byte, format Format) (doc document, err error) {
+	doc.format = format
+	switch format {
+	case JSONFormat:
+		doc.json = data
+		doc.problem, doc.violations, err = rfc9457.ValidateJSON(data)
+	case XMLFormat:
+		doc.problem = &rfc9457.Response{}
+		err = rfc9457.UnmarshalProblemXML(data, doc.problem)
+		if err == nil {
+			doc.violations = rfc9457.Validate(doc.problem)
+		}
+	case CBORFormat:
+		var value any
+		value, err = cbor.Unmarshal(data)
+		if err != nil {
+			goto end
+		}
+		doc.json, err = json.Marshal(value)
+		if err != nil {
+			goto end
+		}
+		doc.problem, doc.violations, err = rfc9457.ValidateJSON(doc.json)
+	default:
+		err = fmt.Errorf("unknown format %q; expected json, xml or cbor", format)
+	}
+end:
+	return doc, err
+}
+
+// encodeDocument writes doc in format. JSON and CBOR output keep the
+// extension members of JSON and CBOR input; XML output has only what
+// Response holds.
+func encodeDocument(doc document, format Format) (data []byte, err error) {
+	var value any
+
+	data = doc.json
+	if data == nil && format != XMLFormat {
+		data, err = json.Marshal(doc.problem)
+		if err != nil {
+			goto end
+		}
+	}
+	switch format {
+	case JSONFormat:
+		data, err = rfc9457.Canonicalizer{KeepIDs: true, KeepTimestamps: true}.JSON(data)
+	case XMLFormat:
+		data, err = rfc9457.MarshalProblemXML(doc.problem)
+	case CBORFormat:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&value)
+		if err != nil {
+			goto end
+		}
+		data, err = cbor.Marshal(value)
+	default:
+		err = fmt.Errorf("unknown format %q; expected json, xml or cbor", format)
+	}
+end:
+	return data, err
+}
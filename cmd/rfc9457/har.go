@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikeschinkel/go-rfc9457/rfc9457har"
+)
+
+func runHAR(args []string, stdin io.Reader, stdout io.Writer) (err error) {
+	var allowed stringList
+	var failed bool
+
+	fs := flag.NewFlagSet("har", flag.ContinueOnError)
+	fs.Var(&allowed, "allow-type", "accept problem types with this prefix (repeatable)")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+	for _, in := range inputs(fs.Args()) {
+		var report *rfc9457har.Report
+		report, err = analyzeHAR(in, stdin, &rfc9457har.Analyzer{KnownType: allowed.knownType})
+		if err != nil {
+			goto end
+		}
+		if len(fs.Args()) > 1 {
+			_, err = io.WriteString(stdout, "== "+in+"\n")
+			if err != nil {
+				goto end
+			}
+		}
+		err = report.WriteText(stdout)
+		if err != nil {
+			goto end
+		}
+		failed = failed || len(report.Findings) > 0
+	}
+	if failed {
+		err = errViolations
+	}
+end:
+	return err
+}
+
+func analyzeHAR(name string, stdin io.Reader, a *rfc9457har.Analyzer) (report *rfc9457har.Report, err error) {
+	r := stdin
+	if name != stdinName {
+		var f *os.File
+		f, err = os.Open(name)
+		if err != nil {
+			goto end
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	report, err = a.Analyze(r)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", name, err)
+	}
+end:
+	return report, err
+}
@@ -0,0 +1,210 @@
+// Command rfc9457 validates, formats, converts and explains problem
+// documents.
+//
+//	rfc9457 validate [-format f] [file...]
+//	rfc9457 fmt [-w] [file...]
+//	rfc9457 convert -to json|xml|cbor [-format f] [-o file] [file]
+//	rfc9457 explain [-format f] [file...]
+//	rfc9457 probe -base url (-openapi file | -routes file) [-allow-type prefix]...
+//	rfc9457 har [-allow-type prefix]... [file...]
+//
+// Input is read from the named files, or from stdin when none or "-" is
+// given. Its format is json, xml or cbor; by default it is inferred from the
+// file extension or, failing that, from the content.
+//
+// validate reports RFC 9457 violations and disagreements with the registered
+// error types and exits 1 if it finds any, so it can gate fixtures in CI.
+// fmt writes documents in canonical JSON form, keeping top-level extension
+// members, in place with -w; only JSON files can be rewritten. convert
+// translates one document between formats. explain renders documents for
+// humans.
+//
+// probe sends malformed requests (bad parameter types, missing parameters,
+// wrong methods, oversized bodies) to a local API described by an OpenAPI 3
+// JSON document or a route list (see rfc9457probe.ParseRoutes). It reports
+// every error reply that is not a valid problem document with a known type,
+// and exits 1 if there are any. Types with an -allow-type prefix are known
+// in addition to the predefined ones.
+//
+// har checks every error response recorded in HAR files for non-compliant
+// bodies, unknown types, status mismatches and leaked internal details,
+// summarizes the problem types seen per endpoint, and exits 1 if anything
+// was found.
+//
+// Every document is decoded with Response.UnmarshalJSON, so what these
+// commands accept is exactly what clients of this package accept.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// errViolations signals a validation failure that has already been reported.
+var errViolations = errors.New("problem documents have violations")
+
+func main() {
+	rfc9457.SetLogger(rfc9457.DiscardLogger)
+	err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case errors.Is(err, errViolations):
+		os.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, "rfc9457: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: rfc9457 validate|fmt|convert|explain|probe|har [flags] [args]")
+	}
+	switch args[0] {
+	case "validate":
+		return runValidate(args[1:], stdin, stdout)
+	case "fmt":
+		return runFmt(args[1:], stdin, stdout)
+	case "convert":
+		return runConvert(args[1:], stdin, stdout)
+	case "explain":
+		return runExplain(args[1:], stdin, stdout)
+	case "probe":
+		return runProbe(args[1:], stdout)
+	case "har":
+		return runHAR(args[1:], stdin, stdout)
+	}
+	return fmt.Errorf("unknown command %q; expected validate, fmt, convert, explain, probe or har", args[0])
+}
+
+func runValidate(args []string, stdin io.Reader, stdout io.Writer) (err error) {
+	var failed bool
+
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: json, xml or cbor (default inferred)")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+	for _, in := range inputs(fs.Args()) {
+		var doc document
+		doc, err = readDocument(in, Format(*format), stdin)
+		if err != nil {
+			goto end
+		}
+		for _, v := range doc.violations {
+			fmt.Fprintf(stdout, "%s: %s\n", in, v)
+			failed = true
+		}
+	}
+	if failed {
+		err = errViolations
+	}
+end:
+	return err
+}
+
+func runFmt(args []string, stdin io.Reader, stdout io.Writer) (err error) {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := fs.Bool("w", false, "rewrite files in place instead of writing to stdout")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+	for _, in := range inputs(fs.Args()) {
+		var doc document
+		var data []byte
+		doc, err = readDocument(in, "", stdin)
+		if err != nil {
+			goto end
+		}
+		if *write && in != stdinName && doc.format != JSONFormat {
+			err = fmt.Errorf("%s is %s, not JSON; fmt -w only rewrites JSON files (see convert)", in, doc.format)
+			goto end
+		}
+		data, err = encodeDocument(doc, JSONFormat)
+		if err != nil {
+			goto end
+		}
+		if *write && in != stdinName {
+			err = os.WriteFile(in, data, 0o644)
+		} else {
+			_, err = stdout.Write(data)
+		}
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+func runConvert(args []string, stdin io.Reader, stdout io.Writer) (err error) {
+	var doc document
+	var data []byte
+	var in string
+
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: json, xml or cbor (default inferred)")
+	to := fs.String("to", "", "output format: json, xml or cbor")
+	out := fs.String("o", "", "output file (default stdout)")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+	if *to == "" || fs.NArg() > 1 {
+		err = fmt.Errorf("usage: rfc9457 convert -to json|xml|cbor [-format f] [-o file] [file]")
+		goto end
+	}
+	in = inputs(fs.Args())[0]
+	doc, err = readDocument(in, Format(*format), stdin)
+	if err != nil {
+		goto end
+	}
+	data, err = encodeDocument(doc, Format(*to))
+	if err != nil {
+		goto end
+	}
+	if *out != "" {
+		err = os.WriteFile(*out, data, 0o644)
+		goto end
+	}
+	_, err = stdout.Write(data)
+end:
+	return err
+}
+
+func runExplain(args []string, stdin io.Reader, stdout io.Writer) (err error) {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: json, xml or cbor (default inferred)")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+	for i, in := range inputs(fs.Args()) {
+		var doc document
+		doc, err = readDocument(in, Format(*format), stdin)
+		if err != nil {
+			goto end
+		}
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		explain(stdout, doc)
+	}
+end:
+	return err
+}
+
+// inputs returns the named files, or stdin when there are none.
+func inputs(args []string) []string {
+	if len(args) == 0 {
+		return []string{stdinName}
+	}
+	return args
+}
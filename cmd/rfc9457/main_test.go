@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const outOfCredit = `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.",` +
+	`"status":403,"detail":"Your current balance is 30, but that costs 50.","balance":30}`
+
+const outOfCreditFormatted = `{
+  "balance": 30,
+  "detail": "Your current balance is 30, but that costs 50.",
+  "status": 403,
+  "title": "You do not have enough credit.",
+  "type": "https://example.com/probs/out-of-credit"
+}
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(file, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantErr    error
+		wantErrMsg string
+		wantOutput string
+	}{
+		{
+			name:  "validate_valid",
+			args:  []string{"validate"},
+			stdin: outOfCredit,
+		},
+		{
+			name:       "validate_violations",
+			args:       []string{"validate"},
+			stdin:      `{"title":5,"status":"404"}`,
+			wantErr:    errViolations,
+			wantOutput: "-: title: must be a string\n-: status: must be an integer\n",
+		},
+		{
+			name:       "validate_malformed",
+			args:       []string{"validate"},
+			stdin:      `{"title":`,
+			wantErrMsg: "-: ",
+		},
+		{
+			name:       "validate_unknown_format",
+			args:       []string{"validate", "-format", "yaml"},
+			stdin:      outOfCredit,
+			wantErrMsg: `unknown format "yaml"`,
+		},
+		{
+			name:       "fmt_keeps_extension_members",
+			args:       []string{"fmt"},
+			stdin:      outOfCredit,
+			wantOutput: outOfCreditFormatted,
+		},
+		{
+			name:       "convert_without_to",
+			args:       []string{"convert"},
+			stdin:      outOfCredit,
+			wantErrMsg: "usage: rfc9457 convert",
+		},
+		{
+			name:       "no_command",
+			wantErrMsg: "usage: rfc9457",
+		},
+		{
+			name:       "unknown_command",
+			args:       []string{"lint"},
+			wantErrMsg: `unknown command "lint"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("run() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantErrMsg != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("run() error = %v, want one containing %q", err, tt.wantErrMsg)
+				}
+			case err != nil:
+				t.Errorf("run() error = %v", err)
+			}
+			if tt.wantOutput != "" && stdout.String() != tt.wantOutput {
+				t.Errorf("Output:\ngot  %s\nwant %s", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRun_FmtWrite(t *testing.T) {
+	var stdout bytes.Buffer
+
+	file := writeFile(t, "problem.json", outOfCredit)
+	err := run([]string{"fmt", "-w", file}, nil, &stdout, &stdout)
+	if err != nil {
+		t.Fatalf("fmt -w: %v", err)
+	}
+	got, _ := os.ReadFile(file)
+	if string(got) != outOfCreditFormatted {
+		t.Errorf("Rewritten file:\ngot  %s\nwant %s", got, outOfCreditFormatted)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("fmt -w wrote to stdout: %s", stdout.String())
+	}
+}
+
+func TestRun_FmtWriteRefusesNonJSON(t *testing.T) {
+	var stdout bytes.Buffer
+
+	xml := `<problem xmlns="urn:ietf:rfc:7807"><title>Not Found</title><status>404</status></problem>`
+	file := writeFile(t, "problem.xml", xml)
+	err := run([]string{"fmt", "-w", file}, nil, &stdout, &stdout)
+	if err == nil || !strings.Contains(err.Error(), "not JSON") {
+		t.Errorf("fmt -w on XML: got %v, want a refusal", err)
+	}
+	got, _ := os.ReadFile(file)
+	if string(got) != xml {
+		t.Errorf("fmt -w changed %s to %s", file, got)
+	}
+}
+
+func TestRun_ConvertRoundTrip(t *testing.T) {
+	var stdout bytes.Buffer
+
+	dir := t.TempDir()
+	in := writeFile(t, "problem.json", outOfCredit)
+	cbor := filepath.Join(dir, "problem.cbor")
+	err := run([]string{"convert", "-to", "cbor", "-o", cbor, in}, nil, &stdout, &stdout)
+	if err != nil {
+		t.Fatalf("convert -to cbor: %v", err)
+	}
+	err = run([]string{"convert", "-to", "json", cbor}, nil, &stdout, &stdout)
+	if err != nil {
+		t.Fatalf("convert -to json: %v", err)
+	}
+	if stdout.String() != outOfCreditFormatted {
+		t.Errorf("Round trip:\ngot  %s\nwant %s", stdout.String(), outOfCreditFormatted)
+	}
+
+	stdout.Reset()
+	err = run([]string{"convert", "-to", "xml", cbor}, nil, &stdout, &stdout)
+	if err != nil {
+		t.Fatalf("convert -to xml: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "<status>403</status>") {
+		t.Errorf("XML output lacks the status: %s", stdout.String())
+	}
+}
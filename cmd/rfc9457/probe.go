@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/rfc9457probe"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// knownType accepts about:blank, registered types and types with one of
+// the prefixes in l.
+func (l stringList) knownType(t rfc9457.ErrorTypeURI) bool {
+	for _, prefix := range l {
+		if strings.HasPrefix(string(t), prefix) {
+			return true
+		}
+	}
+	_, ok := rfc9457.LookupErrorType(t)
+	return ok || t == "" || t == rfc9457.AboutBlankErrorType
+}
+
+func runProbe(args []string, stdout io.Writer) (err error) {
+	var routes []rfc9457probe.Route
+	var report *rfc9457probe.Report
+	var allowed stringList
+
+	fs := flag.NewFlagSet("probe", flag.ContinueOnError)
+	base := fs.String("base", "", "base URL of the API, e.g. http://127.0.0.1:8080")
+	openAPI := fs.String("openapi", "", "OpenAPI 3 document in JSON")
+	routeList := fs.String("routes", "", "route list file")
+	bodySize := fs.Int("body-size", rfc9457probe.DefaultOversizedBodySize, "size in bytes of oversized request bodies")
+	fs.Var(&allowed, "allow-type", "accept problem types with this prefix (repeatable)")
+	err = fs.Parse(args)
+	if err != nil {
+		goto end
+	}
+	if *base == "" || (*openAPI == "") == (*routeList == "") {
+		err = fmt.Errorf("usage: rfc9457 probe -base url (-openapi file | -routes file) [-allow-type prefix]...")
+		goto end
+	}
+
+	routes, err = loadRoutes(*openAPI, *routeList)
+	if err != nil {
+		goto end
+	}
+	report, err = (&rfc9457probe.Prober{
+		BaseURL:           *base,
+		OversizedBodySize: *bodySize,
+		KnownType:         allowed.knownType,
+	}).Run(context.Background(), routes)
+	if err != nil {
+		goto end
+	}
+	err = report.WriteText(stdout)
+	if err == nil && !report.Passed() {
+		err = errViolations
+	}
+end:
+	return err
+}
+
+func loadRoutes(openAPI, routeList string) (routes []rfc9457probe.Route, err error) {
+	var data []byte
+	var f *os.File
+
+	if openAPI != "" {
+		data, err = os.ReadFile(openAPI)
+		if err != nil {
+			goto end
+		}
+		routes, err = rfc9457probe.LoadOpenAPI(data)
+		goto end
+	}
+	f, err = os.Open(routeList)
+	if err != nil {
+		goto end
+	}
+	defer func() { _ = f.Close() }()
+	routes, err = rfc9457probe.ParseRoutes(f)
+end:
+	return routes, err
+}
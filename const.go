@@ -3,4 +3,5 @@ package rfc9457
 const (
 	ApplicationJSON        MIMEType = "application/json"
 	ApplicationProblemJSON MIMEType = "application/problem+json"
+	ApplicationProblemXML  MIMEType = "application/problem+xml"
 )
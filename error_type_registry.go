@@ -0,0 +1,70 @@
+package rfc9457
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrorTypeDefinition describes a registered problem type. Title is the
+// canonical English title; it is what machines match on and is never
+// replaced by localization.
+type ErrorTypeDefinition struct {
+	Type   ErrorTypeURI `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	// Retry overrides the status-based retry classification used by
+	// RetryPolicy for problems of this type.
+	Retry Retryability `json:"retry,omitempty"`
+	// SensitiveDetail marks types whose Detail may carry internal
+	// information, such as SQL text, that ProductionProfile withholds.
+	SensitiveDetail bool `json:"sensitive_detail,omitempty"`
+}
+
+// Retryability says whether problems of a type may be retried.
+type Retryability int
+
+const (
+	// RetryByStatus classifies by status: 408, 425, 429 and most 5xx.
+	RetryByStatus Retryability = iota
+	AlwaysRetry
+	NeverRetry
+)
+
+var errorTypes struct {
+	sync.RWMutex
+	defs map[ErrorTypeURI]ErrorTypeDefinition
+}
+
+// RegisterErrorType adds def to the registry, replacing any existing
+// definition for the same Type.
+func RegisterErrorType(def ErrorTypeDefinition) {
+	errorTypes.Lock()
+	defer errorTypes.Unlock()
+	if errorTypes.defs == nil {
+		errorTypes.defs = make(map[ErrorTypeURI]ErrorTypeDefinition)
+	}
+	errorTypes.defs[def.Type] = def
+}
+
+// LookupErrorType returns the registered definition for t.
+func LookupErrorType(t ErrorTypeURI) (def ErrorTypeDefinition, ok bool) {
+	errorTypes.RLock()
+	defer errorTypes.RUnlock()
+	def, ok = errorTypes.defs[t]
+	return def, ok
+}
+
+// RegisteredErrorTypes returns all registered definitions sorted by Type.
+func RegisteredErrorTypes() []ErrorTypeDefinition {
+	errorTypes.RLock()
+	defs := make([]ErrorTypeDefinition, 0, len(errorTypes.defs))
+	for _, def := range errorTypes.defs {
+		defs = append(defs, def)
+	}
+	errorTypes.RUnlock()
+	slices.SortFunc(defs, func(a, b ErrorTypeDefinition) int {
+		return strings.Compare(string(a.Type), string(b.Type))
+	})
+	return defs
+}
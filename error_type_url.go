@@ -6,6 +6,10 @@ type ErrorTypeURI string
 const (
 	ErrorTypeRootURI  ErrorTypeURI = "https://schema.xmlui.org/errors"
 	TestServerAPIPath ErrorTypeURI = "/test-server/api"
+
+	// AboutBlankErrorType is the RFC 9457 default type, meaning the problem
+	// has no semantics beyond its HTTP status.
+	AboutBlankErrorType ErrorTypeURI = "about:blank"
 )
 const (
 	InvalidParameterErrorType    ErrorTypeURI = uri + path + "/validation/invalid-parameter-type"
@@ -24,6 +28,15 @@ const (
 	CardinalityMismatchErrorType ErrorTypeURI = uri + path + "/routing/cardinality-mismatch"
 	MethodNotAllowedErrorType    ErrorTypeURI = uri + path + "/routing/method-not-allowed"
 	QueryFailedErrorType         ErrorTypeURI = uri + path + "/database/query-failed"
+
+	// MultipleProblemsErrorType is the default type of problems built by
+	// Aggregate. It is not registered because its status depends on the
+	// problems aggregated.
+	MultipleProblemsErrorType ErrorTypeURI = uri + path + "/aggregate/multiple-problems"
+
+	// TimeoutErrorType is the default type of problems written by
+	// TimeoutHandler, with status 503 or 504, so it is not registered either.
+	TimeoutErrorType ErrorTypeURI = uri + path + "/server/timeout"
 )
 
 // Private convenience constants
@@ -31,3 +44,29 @@ const (
 	uri  = ErrorTypeRootURI
 	path = TestServerAPIPath
 )
+
+// predefinedErrorTypes are registered at init so the constants above have
+// canonical titles and statuses.
+var predefinedErrorTypes = []ErrorTypeDefinition{
+	{Type: InvalidParameterErrorType, Title: "Invalid Parameter Type", Status: 422},
+	{Type: ConstraintViolationErrorType, Title: "Constraint Violation", Status: 422},
+	{Type: MissingParametersErrorType, Title: "Missing Required Parameters", Status: 400},
+	{Type: CurrentlyUnhandledErrorType, Title: "Currently Unhandled", Status: 501},
+	{Type: UnauthorizedErrorType, Title: "Unauthorized", Status: 401},
+	{Type: InvalidBodyFormatErrorType, Title: "Invalid Body Format", Status: 400},
+	{Type: InvalidURLFormatErrorType, Title: "Invalid URL Format", Status: 400},
+	{Type: InvalidURLParameterErrorType, Title: "Invalid URL Parameter", Status: 400},
+	{Type: InvalidDBQueryErrorType, Title: "Invalid Database Query", Status: 400, SensitiveDetail: true},
+	{Type: InternalServerErrorType, Title: "Internal Server Error", Status: 500},
+	{Type: EndpointNotMatchedErrorType, Title: "Endpoint Not Matched", Status: 404},
+	{Type: NoResultsErrorType, Title: "No Results", Status: 404},
+	{Type: CardinalityMismatchErrorType, Title: "Cardinality Mismatch", Status: 400},
+	{Type: MethodNotAllowedErrorType, Title: "Method Not Allowed", Status: 405},
+	{Type: QueryFailedErrorType, Title: "Query Failed", Status: 500, SensitiveDetail: true},
+}
+
+func init() {
+	for _, def := range predefinedErrorTypes {
+		RegisterErrorType(def)
+	}
+}
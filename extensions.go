@@ -1,5 +1,10 @@
 package rfc9457
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type Extension interface{}
 
 var registeredExtensions = make([]Extension, 0)
@@ -7,3 +12,60 @@ var registeredExtensions = make([]Extension, 0)
 func RegisterExtension(ext Extension) {
 	registeredExtensions = append(registeredExtensions, ext)
 }
+
+// unmarshalWithMember decodes data into v only if it is an object with the
+// named member, so that a registered extension type matches just the
+// extensions it describes. v must not itself implement json.Unmarshaler.
+func unmarshalWithMember(data []byte, name string, v any) (err error) {
+	var members map[string]json.RawMessage
+
+	err = json.Unmarshal(data, &members)
+	if err != nil {
+		goto end
+	}
+	if _, ok := members[name]; !ok {
+		err = fmt.Errorf("extension has no %q member", name)
+		goto end
+	}
+	err = json.Unmarshal(data, v)
+end:
+	return err
+}
+
+// ExtensionMember returns the value of the named member from the first
+// extension that has it. Extensions of any Go type are inspected through
+// their JSON encoding, so struct extensions match on their JSON field names.
+func (r *Response) ExtensionMember(name string) (value any, ok bool) {
+	for _, ext := range r.Extensions {
+		members, isMap := ext.(map[string]any)
+		if !isMap {
+			data, err := json.Marshal(ext)
+			if err != nil || json.Unmarshal(data, &members) != nil {
+				continue
+			}
+		}
+		value, ok = members[name]
+		if ok {
+			break
+		}
+	}
+	return value, ok
+}
+
+// RetryableExtension lets a server state explicitly whether a problem may be
+// retried, overriding client-side classification.
+type RetryableExtension struct {
+	Retryable bool `json:"retryable"`
+}
+
+// RateLimitExtension describes the rate limit a problem was caused by.
+type RateLimitExtension struct {
+	RateLimit RateLimit `json:"rate_limit"`
+}
+
+type RateLimit struct {
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+	// Reset is the number of seconds until the limit resets.
+	Reset int `json:"reset"`
+}
@@ -0,0 +1,178 @@
+package rfc9457
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var _ fmt.Formatter = (*Response)(nil)
+
+// maxFormattedCauses bounds the cause chain written by %+v.
+const maxFormattedCauses = 16
+
+// Format implements fmt.Formatter:
+//
+//	%v, %s  one line: "404 https://example.com/errors/no-results: No Results"
+//	%q      the one-line form, quoted
+//	%+v     one key=value field per line: status, type, title, detail,
+//	        instance, occurrence_id, extensions.<member>, cause.<n> and
+//	        stack.<n>
+//
+// Values containing spaces, quotes, "=" or control characters, and empty
+// values, are quoted with strconv.Quote. ParseFields reads either form back.
+func (r *Response) Format(s fmt.State, verb rune) {
+	if r == nil {
+		_, _ = io.WriteString(s, "<nil>")
+		return
+	}
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		for i, f := range r.fields() {
+			if i > 0 {
+				_, _ = io.WriteString(s, "\n")
+			}
+			_, _ = io.WriteString(s, f.key+"="+quoteFieldValue(f.value))
+		}
+	case verb == 'v' || verb == 's':
+		_, _ = io.WriteString(s, r.summary())
+	case verb == 'q':
+		_, _ = io.WriteString(s, strconv.Quote(r.summary()))
+	default:
+		_, _ = fmt.Fprintf(s, "%%!%c(*rfc9457.Response=%s)", verb, r.summary())
+	}
+}
+
+func (r *Response) summary() string {
+	typ := r.Type
+	if typ == "" {
+		typ = AboutBlankErrorType
+	}
+	return fmt.Sprintf("%d %s: %s", r.Status, typ, r.Title)
+}
+
+type formatField struct {
+	key, value string
+}
+
+func (r *Response) fields() []formatField {
+	fields := []formatField{
+		{"status", strconv.Itoa(r.Status)},
+		{"type", string(r.Type)},
+		{"title", r.Title},
+	}
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, formatField{key, value})
+		}
+	}
+	add("detail", r.Detail)
+	add("instance", r.Instance)
+	add("occurrence_id", r.OccurrenceID)
+	for i, ext := range r.Extensions {
+		data, err := json.Marshal(ext)
+		if err != nil {
+			add(fmt.Sprintf("extensions.%d", i), fmt.Sprintf("<unencodable %T>", ext))
+			continue
+		}
+		var members map[string]json.RawMessage
+		if json.Unmarshal(data, &members) != nil || members == nil {
+			add(fmt.Sprintf("extensions.%d", i), string(data))
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(members)) {
+			value := string(members[name])
+			var str string
+			if json.Unmarshal(members[name], &str) == nil {
+				value = str
+			}
+			add("extensions."+name, value)
+		}
+	}
+	for i, cause := range causeChain(r.cause) {
+		add(fmt.Sprintf("cause.%d", i), cause.Error())
+	}
+	for i, frame := range r.stack {
+		add(fmt.Sprintf("stack.%d", i), fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+	}
+	return fields
+}
+
+// causeChain flattens err and the errors it wraps, breadth first.
+func causeChain(err error) (chain []error) {
+	queue := []error{err}
+	for len(queue) > 0 && len(chain) < maxFormattedCauses {
+		next := queue[0]
+		queue = queue[1:]
+		if next == nil {
+			continue
+		}
+		chain = append(chain, next)
+		switch u := next.(type) {
+		case interface{ Unwrap() []error }:
+			queue = append(queue, u.Unwrap()...)
+		default:
+			queue = append(queue, errors.Unwrap(next))
+		}
+	}
+	return chain
+}
+
+func quoteFieldValue(v string) string {
+	if v == "" || strings.ContainsFunc(v, func(c rune) bool {
+		return c <= ' ' || c == '"' || c == '=' || c == 0x7f
+	}) {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+// ParseFields parses the output of %v, %q or %+v back into fields. The
+// one-line forms yield status, type and title.
+func ParseFields(text string) (fields map[string]string, err error) {
+	var scanner *bufio.Scanner
+
+	fields = make(map[string]string)
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, `"`) {
+		text, err = strconv.Unquote(text)
+		if err != nil {
+			goto end
+		}
+	}
+	if !strings.HasPrefix(text, "status=") {
+		status, rest, _ := strings.Cut(text, " ")
+		typ, title, ok := strings.Cut(rest, ": ")
+		if !ok {
+			err = fmt.Errorf("not a formatted problem: %q", text)
+			goto end
+		}
+		fields["status"], fields["type"], fields["title"] = status, typ, title
+		goto end
+	}
+	scanner = bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			err = fmt.Errorf("not a key=value field: %q", scanner.Text())
+			goto end
+		}
+		if strings.HasPrefix(value, `"`) {
+			value, err = strconv.Unquote(value)
+			if err != nil {
+				err = fmt.Errorf("field %s: %w", key, err)
+				goto end
+			}
+		}
+		fields[key] = value
+	}
+	err = scanner.Err()
+end:
+	return fields, err
+}
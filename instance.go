@@ -0,0 +1,269 @@
+package rfc9457
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestIDHeader is the header consulted by RequestIDGenerator when no other
+// header name is given.
+const RequestIDHeader = "X-Request-ID"
+
+// MaxRequestIDLength is the longest header value RequestIDGenerator passes
+// through.
+const MaxRequestIDLength = 128
+
+// DefaultURNNamespace is the URN namespace of the IDs of UUIDv4Generator and
+// UUIDv7Generator, used for their instances when InstanceArgs.URNNamespace
+// is empty.
+const DefaultURNNamespace = "uuid"
+
+// IDGenerator produces the identifier for a single problem occurrence.
+type IDGenerator interface {
+	GenerateID(req *http.Request) string
+}
+
+// IDGeneratorFunc adapts an ordinary function to the IDGenerator interface.
+type IDGeneratorFunc func(req *http.Request) string
+
+func (f IDGeneratorFunc) GenerateID(req *http.Request) string {
+	return f(req)
+}
+
+// URNNamespacer is implemented by IDGenerators whose IDs belong to a URN
+// namespace, which NewInstanceGenerator then uses by default.
+type URNNamespacer interface {
+	URNNamespace() string
+}
+
+// uuidGenerator generates UUIDs, whose URN namespace is "uuid" (RFC 9562).
+type uuidGenerator func() string
+
+func (g uuidGenerator) GenerateID(*http.Request) string {
+	return g()
+}
+
+func (uuidGenerator) URNNamespace() string {
+	return DefaultURNNamespace
+}
+
+// Built-in ID generators. Only the UUID generators have a URN namespace.
+var (
+	UUIDv4Generator IDGenerator = uuidGenerator(NewUUIDv4)
+	UUIDv7Generator IDGenerator = uuidGenerator(NewUUIDv7)
+	ULIDGenerator   IDGenerator = IDGeneratorFunc(func(*http.Request) string { return NewULID() })
+)
+
+// ErrNoURNNamespace is returned by NewInstanceGenerator when instances would
+// be URNs but the IDGenerator has no URN namespace and none was given.
+var ErrNoURNNamespace = errors.New("URN namespace required for IDs that are not UUIDs")
+
+// RequestIDGenerator passes through the value of the named request header,
+// falling back to the given generator when the header is absent, longer than
+// MaxRequestIDLength or not made only of URI unreserved characters
+// (letters, digits, "-", ".", "_" and "~"), since the value is embedded in
+// the Instance URI. An empty header defaults to RequestIDHeader and a nil
+// fallback to UUIDv4Generator.
+func RequestIDGenerator(header string, fallback IDGenerator) IDGenerator {
+	if header == "" {
+		header = RequestIDHeader
+	}
+	if fallback == nil {
+		fallback = UUIDv4Generator
+	}
+	return IDGeneratorFunc(func(req *http.Request) (id string) {
+		if req != nil {
+			id = strings.TrimSpace(req.Header.Get(header))
+		}
+		if !isRequestIDToken(id) {
+			id = fallback.GenerateID(req)
+		}
+		return id
+	})
+}
+
+func isRequestIDToken(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUIDv4 returns a random (version 4) UUID in its canonical string form.
+func NewUUIDv4() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return formatUUID(u)
+}
+
+// NewUUIDv7 returns a time-ordered (version 7) UUID in its canonical string form.
+func NewUUIDv7() string {
+	var u [16]byte
+	_, _ = rand.Read(u[6:])
+	ms := uint64(time.Now().UnixMilli())
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	u[6] = (u[6] & 0x0f) | 0x70
+	u[8] = (u[8] & 0x3f) | 0x80
+	return formatUUID(u)
+}
+
+func formatUUID(u [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID (48-bit millisecond timestamp followed by 80 random
+// bits) encoded as 26 characters of Crockford base32.
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[0:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(b[6:])
+
+	// 128 bits encode into 26 base32 characters; the first carries 3 bits
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&0x1f]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Occurrence identifies a single emitted problem. ID is the raw identifier
+// and Instance is the URI form suitable for Response.Instance.
+type Occurrence struct {
+	ID       string
+	Instance string
+}
+
+type InstanceArgs struct {
+	// IDGenerator produces occurrence IDs; defaults to UUIDv4Generator.
+	IDGenerator IDGenerator
+	// OccurrencesRootURI, when set, causes instances to be URLs of the form
+	// {OccurrencesRootURI}/{id} rather than URNs.
+	OccurrencesRootURI string
+	// URNNamespace is the NID used for URN instances. It defaults to the
+	// namespace of an IDGenerator that is a URNNamespacer, such as the UUID
+	// generators, and is otherwise required unless OccurrencesRootURI is
+	// set.
+	URNNamespace string
+}
+
+type InstanceGenerator struct {
+	idGenerator IDGenerator
+	rootURI     string
+	urnNS       string
+}
+
+// NewInstanceGenerator returns an InstanceGenerator, or ErrNoURNNamespace if
+// it would produce URNs without a valid namespace, such as urn:uuid: for a
+// ULID or a request ID.
+func NewInstanceGenerator(args InstanceArgs) (g *InstanceGenerator, err error) {
+	g = &InstanceGenerator{
+		idGenerator: args.IDGenerator,
+		rootURI:     strings.TrimRight(args.OccurrencesRootURI, "/"),
+		urnNS:       args.URNNamespace,
+	}
+	if g.idGenerator == nil {
+		g.idGenerator = UUIDv4Generator
+	}
+	if g.urnNS == "" {
+		if ns, ok := g.idGenerator.(URNNamespacer); ok {
+			g.urnNS = ns.URNNamespace()
+		}
+	}
+	if g.urnNS == "" && g.rootURI == "" {
+		g, err = nil, ErrNoURNNamespace
+	}
+	return g, err
+}
+
+// NewOccurrence generates a fresh occurrence ID for req and formats it as an
+// instance URI.
+func (g *InstanceGenerator) NewOccurrence(req *http.Request) Occurrence {
+	id := g.idGenerator.GenerateID(req)
+	return Occurrence{
+		ID:       id,
+		Instance: g.InstanceURI(id),
+	}
+}
+
+// InstanceURI formats an already-generated ID as an instance URI.
+func (g *InstanceGenerator) InstanceURI(id string) string {
+	if g.rootURI != "" {
+		return g.rootURI + "/" + id
+	}
+	return "urn:" + g.urnNS + ":" + id
+}
+
+// OccurrenceID extracts the ID from an instance URI produced by this
+// generator, returning false if the instance was not produced by it.
+func (g *InstanceGenerator) OccurrenceID(instance string) (id string, ok bool) {
+	var prefix string
+	if g.rootURI != "" {
+		prefix = g.rootURI + "/"
+	} else {
+		prefix = "urn:" + g.urnNS + ":"
+	}
+	id, ok = strings.CutPrefix(instance, prefix)
+	if id == "" {
+		ok = false
+	}
+	return id, ok
+}
+
+var instanceGenerator struct {
+	sync.RWMutex
+	g *InstanceGenerator
+}
+
+// SetInstanceGenerator installs the generator NewResponse uses to assign
+// unique instances when ResponseArgs.Request is set and ResponseArgs.Instance
+// is empty. Pass nil to disable generation.
+func SetInstanceGenerator(g *InstanceGenerator) {
+	instanceGenerator.Lock()
+	instanceGenerator.g = g
+	instanceGenerator.Unlock()
+}
+
+// GetInstanceGenerator returns the generator installed by SetInstanceGenerator,
+// or nil if none.
+func GetInstanceGenerator() *InstanceGenerator {
+	instanceGenerator.RLock()
+	defer instanceGenerator.RUnlock()
+	return instanceGenerator.g
+}
@@ -0,0 +1,411 @@
+// Package cbor encodes and decodes the subset of CBOR (RFC 8949) needed to
+// carry problem documents: the JSON data model plus byte strings. Maps are
+// written in core deterministic order; tags are accepted and discarded on
+// decode.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// maxDepth bounds nesting when decoding untrusted input.
+const maxDepth = 64
+
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+)
+
+const indefinite = 31
+
+var ErrTruncated = errors.New("cbor: unexpected end of data")
+
+// Marshal encodes v, which may be nil, bool, string, []byte, any integer or
+// float type, json.Number, []any or map[string]any.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	err := encode(&buf, v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v any) (err error) {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if t {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case string:
+		writeHead(buf, majorText, uint64(len(t)))
+		buf.WriteString(t)
+	case []byte:
+		writeHead(buf, majorBytes, uint64(len(t)))
+		buf.Write(t)
+	case int:
+		encodeInt(buf, int64(t))
+	case int64:
+		encodeInt(buf, t)
+	case uint64:
+		writeHead(buf, majorUnsigned, t)
+	case float64:
+		encodeFloat(buf, t)
+	case float32:
+		encodeFloat(buf, float64(t))
+	case json.Number:
+		if i, intErr := t.Int64(); intErr == nil {
+			encodeInt(buf, i)
+			goto end
+		}
+		var f float64
+		f, err = strconv.ParseFloat(string(t), 64)
+		if err != nil {
+			err = fmt.Errorf("cbor: invalid number %q", t)
+			goto end
+		}
+		encodeFloat(buf, f)
+	case []any:
+		writeHead(buf, majorArray, uint64(len(t)))
+		for _, item := range t {
+			err = encode(buf, item)
+			if err != nil {
+				goto end
+			}
+		}
+	case map[string]any:
+		err = encodeMap(buf, t)
+	default:
+		err = fmt.Errorf("cbor: unsupported type %T", v)
+	}
+end:
+	return err
+}
+
+// encodeMap writes keys sorted by their encoded bytes, which for text keys
+// is shorter first, then bytewise (RFC 8949 Section 4.2.1).
+func encodeMap(buf *bytes.Buffer, m map[string]any) (err error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return bytes.Compare([]byte(a), []byte(b))
+	})
+	writeHead(buf, majorMap, uint64(len(keys)))
+	for _, key := range keys {
+		err = encode(buf, key)
+		if err != nil {
+			goto end
+		}
+		err = encode(buf, m[key])
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+func encodeInt(buf *bytes.Buffer, i int64) {
+	if i >= 0 {
+		writeHead(buf, majorUnsigned, uint64(i))
+		return
+	}
+	writeHead(buf, majorNegative, uint64(-(i + 1)))
+}
+
+// encodeFloat uses the shortest of single or double precision that
+// represents f exactly.
+func encodeFloat(buf *bytes.Buffer, f float64) {
+	if f32 := float32(f); float64(f32) == f || math.IsNaN(f) {
+		buf.WriteByte(majorSimple<<5 | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(f32)))
+		return
+	}
+	buf.WriteByte(majorSimple<<5 | 27)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+func writeHead(buf *bytes.Buffer, major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		buf.WriteByte(m | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{m | 24, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(m | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		buf.WriteByte(m | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		buf.WriteByte(m | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+// Unmarshal decodes a single CBOR data item into nil, bool, string, []byte,
+// int64, uint64, float64, []any or map[string]any.
+func Unmarshal(data []byte) (v any, err error) {
+	d := decoder{data: data}
+	v, err = d.value(0)
+	if err != nil {
+		goto end
+	}
+	if d.pos != len(d.data) {
+		err = fmt.Errorf("cbor: %d bytes of trailing data", len(d.data)-d.pos)
+	}
+end:
+	return v, err
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+// errBreak is returned by value when it reads the "break" stop code that
+// ends an indefinite-length item.
+var errBreak = errors.New("cbor: unexpected break")
+
+func (d *decoder) value(depth int) (v any, err error) {
+	var major, info byte
+	var n uint64
+
+	if depth > maxDepth {
+		err = fmt.Errorf("cbor: nesting exceeds %d levels", maxDepth)
+		goto end
+	}
+	major, info, n, err = d.head()
+	if err != nil {
+		goto end
+	}
+	switch major {
+	case majorUnsigned:
+		if n <= math.MaxInt64 {
+			v = int64(n)
+		} else {
+			v = n
+		}
+	case majorNegative:
+		if n > math.MaxInt64 {
+			err = fmt.Errorf("cbor: negative integer out of range")
+			goto end
+		}
+		v = -int64(n) - 1
+	case majorBytes, majorText:
+		var b []byte
+		b, err = d.stringBytes(major, info, n)
+		if err != nil {
+			goto end
+		}
+		if major == majorText {
+			v = string(b)
+		} else {
+			v = b
+		}
+	case majorArray:
+		v, err = d.array(info, n, depth)
+	case majorMap:
+		v, err = d.mapValue(info, n, depth)
+	case majorTag:
+		v, err = d.value(depth + 1)
+	case majorSimple:
+		v, err = d.simple(info, n)
+	}
+end:
+	return v, err
+}
+
+// head reads an initial byte and argument. For floats n holds the raw bits.
+func (d *decoder) head() (major, info byte, n uint64, err error) {
+	var size int
+
+	if d.pos >= len(d.data) {
+		err = ErrTruncated
+		goto end
+	}
+	major = d.data[d.pos] >> 5
+	info = d.data[d.pos] & 0x1f
+	d.pos++
+	switch {
+	case info < 24:
+		n = uint64(info)
+		goto end
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == indefinite:
+		if major == majorUnsigned || major == majorNegative || major == majorTag {
+			err = fmt.Errorf("cbor: indefinite length not allowed for major type %d", major)
+		}
+		goto end
+	default:
+		err = fmt.Errorf("cbor: reserved additional information %d", info)
+		goto end
+	}
+	if len(d.data)-d.pos < size {
+		err = ErrTruncated
+		goto end
+	}
+	for _, b := range d.data[d.pos : d.pos+size] {
+		n = n<<8 | uint64(b)
+	}
+	d.pos += size
+end:
+	return major, info, n, err
+}
+
+func (d *decoder) stringBytes(major, info byte, n uint64) (b []byte, err error) {
+	if info != indefinite {
+		if uint64(len(d.data)-d.pos) < n {
+			err = ErrTruncated
+			goto end
+		}
+		b = slices.Clone(d.data[d.pos : d.pos+int(n)])
+		d.pos += int(n)
+		goto end
+	}
+	b = []byte{}
+	for {
+		var chunkMajor, chunkInfo byte
+		if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+			d.pos++
+			goto end
+		}
+		chunkMajor, chunkInfo, n, err = d.head()
+		if err != nil {
+			goto end
+		}
+		if chunkMajor != major || chunkInfo == indefinite {
+			err = fmt.Errorf("cbor: invalid chunk in indefinite-length string")
+			goto end
+		}
+		var chunk []byte
+		chunk, err = d.stringBytes(major, chunkInfo, n)
+		if err != nil {
+			goto end
+		}
+		b = append(b, chunk...)
+	}
+end:
+	return b, err
+}
+
+func (d *decoder) array(info byte, n uint64, depth int) (_ any, err error) {
+	items := []any{}
+	for i := uint64(0); info == indefinite || i < n; i++ {
+		var item any
+		if info == indefinite && d.pos < len(d.data) && d.data[d.pos] == 0xff {
+			d.pos++
+			break
+		}
+		item, err = d.value(depth + 1)
+		if err != nil {
+			goto end
+		}
+		items = append(items, item)
+	}
+end:
+	return items, err
+}
+
+func (d *decoder) mapValue(info byte, n uint64, depth int) (_ any, err error) {
+	m := map[string]any{}
+	for i := uint64(0); info == indefinite || i < n; i++ {
+		var key, value any
+		var ok bool
+		var name string
+		if info == indefinite && d.pos < len(d.data) && d.data[d.pos] == 0xff {
+			d.pos++
+			break
+		}
+		key, err = d.value(depth + 1)
+		if err != nil {
+			goto end
+		}
+		name, ok = key.(string)
+		if !ok {
+			err = fmt.Errorf("cbor: map key of type %T is not a text string", key)
+			goto end
+		}
+		value, err = d.value(depth + 1)
+		if err != nil {
+			goto end
+		}
+		m[name] = value
+	}
+end:
+	return m, err
+}
+
+func (d *decoder) simple(info byte, n uint64) (v any, err error) {
+	switch info {
+	case 20:
+		v = false
+	case 21:
+		v = true
+	case 22, 23:
+		v = nil
+	case 25:
+		v = halfToFloat(uint16(n))
+	case 26:
+		v = float64(math.Float32frombits(uint32(n)))
+	case 27:
+		v = math.Float64frombits(n)
+	case indefinite:
+		err = errBreak
+	default:
+		err = fmt.Errorf("cbor: unsupported simple value %d", n)
+	}
+	return v, err
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
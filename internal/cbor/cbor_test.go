@@ -0,0 +1,277 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustHex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad hex %q: %v", s, err)
+	}
+	return b
+}
+
+// TestUnmarshal_RFC8949Vectors decodes the examples of RFC 8949 Appendix A
+// that fall within the JSON data model plus byte strings.
+func TestUnmarshal_RFC8949Vectors(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want any
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1a000f4240", int64(1000000)},
+		{"1b000000e8d4a51000", int64(1000000000000)},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"20", int64(-1)},
+		{"3863", int64(-100)},
+		{"3903e7", int64(-1000)},
+		{"f90000", 0.0},
+		{"f93c00", 1.0},
+		{"fb3ff199999999999a", 1.1},
+		{"f93e00", 1.5},
+		{"f97bff", 65504.0},
+		{"fa47c35000", 100000.0},
+		{"fa7f7fffff", 3.4028234663852886e+38},
+		{"fb7e37e43c8800759c", 1.0e+300},
+		{"f90001", 5.960464477539063e-8},
+		{"f90400", 0.00006103515625},
+		{"f9c400", -4.0},
+		{"fbc010666666666666", -4.1},
+		{"f97c00", math.Inf(1)},
+		{"f9fc00", math.Inf(-1)},
+		{"fa7f800000", math.Inf(1)},
+		{"fbfff0000000000000", math.Inf(-1)},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f7", nil},
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+		{"c11a514b67b0", int64(1363896240)},
+		{"d74401020304", []byte{1, 2, 3, 4}},
+		{"d818456449455446", []byte("dIETF")},
+		{"40", []byte{}},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"60", ""},
+		{"6161", "a"},
+		{"6449455446", "IETF"},
+		{"62225c", `"\`},
+		{"62c3bc", "ü"},
+		{"63e6b0b4", "水"},
+		{"64f0908591", "𐅑"},
+		{"80", []any{}},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"8301820203820405", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"a0", map[string]any{}},
+		{"a26161016162820203", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"826161a161626163", []any{"a", map[string]any{"b": "c"}}},
+		{"a56161614161626142616361436164614461656145", map[string]any{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}},
+		// Indefinite lengths
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9fff", []any{}},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"9f01820203820405ff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"83018202039f0405ff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"bf61610161629f0203ffff", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"826161bf61626163ff", []any{"a", map[string]any{"b": "c"}}},
+		{"bf6346756ef563416d7421ff", map[string]any{"Fun": true, "Amt": int64(-2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			got, err := Unmarshal(mustHex(t, tt.hex))
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_HalfFloat(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want float64
+	}{
+		{"f98000", math.Copysign(0, -1)},
+		{"f903ff", 0.00006097555160522461}, // largest subnormal
+		{"f97800", 32768},
+		{"f9bc00", -1},
+	}
+	for _, tt := range tests {
+		got, err := Unmarshal(mustHex(t, tt.hex))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.hex, err)
+		}
+		f := got.(float64)
+		if f != tt.want || math.Signbit(f) != math.Signbit(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.hex, f, tt.want)
+		}
+	}
+	got, err := Unmarshal(mustHex(t, "f97e00"))
+	if f, ok := got.(float64); err != nil || !ok || !math.IsNaN(f) {
+		t.Errorf("f97e00: got %v, %v, want NaN", got, err)
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+		want string
+	}{
+		{"empty", "", "unexpected end"},
+		{"truncated_argument", "1903", "unexpected end"},
+		{"truncated_string", "6261", "unexpected end"},
+		{"truncated_array", "8301", "unexpected end"},
+		{"unterminated_indefinite_array", "9f01", "unexpected end"},
+		{"unterminated_indefinite_string", "7f6161", "unexpected end"},
+		{"trailing_data", "0000", "trailing data"},
+		{"reserved_additional_information", "1c", "reserved"},
+		{"indefinite_integer", "1f", "indefinite length not allowed"},
+		{"indefinite_tag", "df00", "indefinite length not allowed"},
+		{"mismatched_chunk", "5f6161ff", "invalid chunk"},
+		{"nested_indefinite_chunk", "7f7fffff", "invalid chunk"},
+		{"lone_break", "ff", "unexpected break"},
+		{"break_in_definite_array", "81ff", "unexpected break"},
+		{"integer_map_key", "a201020304", "not a text string"},
+		{"negative_out_of_range", "3bffffffffffffffff", "out of range"},
+		{"unassigned_simple_value", "f0", "unsupported simple value"},
+		{"huge_definite_length", "5bffffffffffffffff", "unexpected end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal(mustHex(t, tt.hex))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_MaxDepth(t *testing.T) {
+	nested := func(prefix byte, levels int) []byte {
+		return append(bytes.Repeat([]byte{prefix}, levels), 0x00)
+	}
+	if _, err := Unmarshal(nested(0x81, maxDepth)); err != nil {
+		t.Errorf("%d nested arrays: %v", maxDepth, err)
+	}
+	for name, prefix := range map[string]byte{"arrays": 0x81, "tags": 0xc1} {
+		if _, err := Unmarshal(nested(prefix, maxDepth+1)); err == nil || !strings.Contains(err.Error(), "nesting") {
+			t.Errorf("%d nested %s: got %v, want a nesting error", maxDepth+1, name, err)
+		}
+	}
+	deep := append(bytes.Repeat([]byte{0x9f}, 100_000), 0xff)
+	if _, err := Unmarshal(deep); err == nil {
+		t.Error("deeply nested indefinite arrays were accepted")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		hex  string
+	}{
+		{"zero", 0, "00"},
+		{"one_byte_argument", int64(24), "1818"},
+		{"two_byte_argument", 1000, "1903e8"},
+		{"uint64_max", uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{"negative", -1000, "3903e7"},
+		{"json_integer", json.Number("100"), "1864"},
+		{"single_precision", 100000.0, "fa47c35000"},
+		{"double_precision", 1.1, "fb3ff199999999999a"},
+		{"json_float", json.Number("1.5"), "fa3fc00000"},
+		{"bool_and_null", []any{true, false, nil}, "83f5f4f6"},
+		{"text", "IETF", "6449455446"},
+		{"bytes", []byte{1, 2, 3, 4}, "4401020304"},
+		{"deterministic_key_order", map[string]any{"bb": 1, "a": 2, "aa": 3}, "a36161026261610362626201"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if hex.EncodeToString(got) != tt.hex {
+				t.Errorf("got %x, want %s", got, tt.hex)
+			}
+		})
+	}
+	if _, err := Marshal(struct{}{}); err == nil {
+		t.Error("Marshal accepted a struct")
+	}
+	if _, err := Marshal(json.Number("1e")); err == nil {
+		t.Error("Marshal accepted an invalid json.Number")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	doc := `{"type":"https://example.com/errors/out-of-credit","title":"You do not have enough credit.",` +
+		`"status":403,"detail":"Your current balance is 30, but that costs 50.","balance":30.5,` +
+		`"accounts":["/account/12345","/account/67890"],"retryable":false,"limits":null}`
+	var value any
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	data, err := Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	got, _ := json.Marshal(decoded)
+	want, _ := json.Marshal(value)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Round trip:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+// FuzzUnmarshal checks that decoding never panics and that whatever decodes
+// re-encodes to a fixed point.
+func FuzzUnmarshal(f *testing.F) {
+	for _, seed := range []string{
+		"a26161016162820203", "9f018202039f0405ffff", "5f42010243030405ff",
+		"7f657374726561646d696e67ff", "f97e00", "c11a514b67b0", "1bffffffffffffffff",
+		"bf6346756ef563416d7421ff", "8181818100",
+	} {
+		f.Add(mustHex(f, seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v, err := Unmarshal(data)
+		if err != nil {
+			return
+		}
+		first, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal of decoded %#v: %v", v, err)
+		}
+		v2, err := Unmarshal(first)
+		if err != nil {
+			t.Fatalf("Unmarshal of re-encoded %x: %v", first, err)
+		}
+		second, err := Marshal(v2)
+		if err != nil {
+			t.Fatalf("Marshal of %#v: %v", v2, err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Errorf("Re-encoding is not stable:\n%x\n%x", first, second)
+		}
+	})
+}
@@ -0,0 +1,436 @@
+// Package msgextract finds translatable problem strings in Go source without
+// type-checking, resolving ErrorTypeURI constants across packages by parsing
+// their declarations.
+package msgextract
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// Extract parses the non-test Go files in each of dirs and returns a template
+// unit for every problem title and catalog message whose type and text can
+// be resolved statically. Problems that prevent resolving individual strings
+// are returned as warnings rather than failing the extraction.
+func Extract(dirs []string) (units []rfc9457.TranslationUnit, warnings []error, err error) {
+	x := &extractor{
+		fset:    token.NewFileSet(),
+		pkgs:    make(map[string]*pkg),
+		imports: make(map[importKey]*build.Package),
+		units:   make(map[rfc9457.MessageKey]rfc9457.TranslationUnit),
+
+		fallbackTitles: make(map[rfc9457.MessageKey]rfc9457.TranslationUnit),
+	}
+	for _, dir := range dirs {
+		var p *pkg
+		p, err = x.loadDir(dir)
+		if err != nil {
+			goto end
+		}
+		for _, f := range p.files {
+			x.scanFile(p, f)
+		}
+	}
+	// Titles from ResponseArgs vary by call site, so they are only used
+	// for types that have no definition
+	for key, unit := range x.fallbackTitles {
+		_, defined := x.units[key]
+		_, registered := rfc9457.LookupErrorType(key.Type)
+		if !defined && !registered {
+			x.units[key] = unit
+		}
+	}
+	units = make([]rfc9457.TranslationUnit, 0, len(x.units))
+	for _, unit := range x.units {
+		units = append(units, unit)
+	}
+	units = rfc9457.SortTranslationUnits(units)
+	warnings = x.warnings
+end:
+	return units, warnings, err
+}
+
+type extractor struct {
+	fset     *token.FileSet
+	pkgs     map[string]*pkg
+	imports  map[importKey]*build.Package
+	units    map[rfc9457.MessageKey]rfc9457.TranslationUnit
+	warnings []error
+
+	fallbackTitles map[rfc9457.MessageKey]rfc9457.TranslationUnit
+}
+
+type pkg struct {
+	dir    string
+	name   string
+	files  []*ast.File
+	consts map[string]ast.Expr
+	types  map[string]bool
+}
+
+type importKey struct {
+	path, fromDir string
+}
+
+func (x *extractor) loadDir(dir string) (p *pkg, err error) {
+	var entries []os.DirEntry
+	var ok bool
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		goto end
+	}
+	p, ok = x.pkgs[dir]
+	if ok {
+		goto end
+	}
+	entries, err = os.ReadDir(dir)
+	if err != nil {
+		goto end
+	}
+	p = &pkg{dir: dir, consts: make(map[string]ast.Expr), types: make(map[string]bool)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		var f *ast.File
+		f, err = parser.ParseFile(x.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			goto end
+		}
+		p.name = f.Name.Name
+		p.files = append(p.files, f)
+		collectDecls(p, f)
+	}
+	x.pkgs[dir] = p
+end:
+	return p, err
+}
+
+// collectDecls records the package-level constants and type names in f.
+func collectDecls(p *pkg, f *ast.File) {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		if gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				p.types[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+			continue
+		}
+		if gd.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if i < len(vs.Values) {
+					p.consts[name.Name] = vs.Values[i]
+				}
+			}
+		}
+	}
+}
+
+// importedPkg resolves the package a selector's qualifier refers to in f.
+func (x *extractor) importedPkg(from *pkg, f *ast.File, qualifier string) (*pkg, error) {
+	if f == nil {
+		return nil, fmt.Errorf("no file scope for %s", qualifier)
+	}
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil && imp.Name.Name != qualifier {
+			continue
+		}
+		bp, err := x.findImport(path, from.dir)
+		if err != nil {
+			return nil, err
+		}
+		if bp.Goroot {
+			// Standard library packages never declare ErrorTypeURIs
+			continue
+		}
+		p, err := x.loadDir(bp.Dir)
+		if err != nil {
+			return nil, err
+		}
+		if p.name == qualifier {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no import found for %s", qualifier)
+}
+
+// findImport locates the package path imports from fromDir. Packages of
+// the module containing fromDir are resolved from its go.mod, since
+// go/build takes any path without a dot, such as one in a module named
+// "test", to be in the standard library.
+func (x *extractor) findImport(path, fromDir string) (bp *build.Package, err error) {
+	key := importKey{path: path, fromDir: fromDir}
+	bp, ok := x.imports[key]
+	if ok {
+		goto end
+	}
+	if dir, ok := moduleDir(path, fromDir); ok {
+		bp = &build.Package{ImportPath: path, Dir: dir}
+		x.imports[key] = bp
+		goto end
+	}
+	bp, err = build.Import(path, fromDir, build.FindOnly)
+	if err != nil {
+		err = fmt.Errorf("resolving import %s: %w", path, err)
+		goto end
+	}
+	x.imports[key] = bp
+end:
+	return bp, err
+}
+
+// moduleDir returns the directory of package path if it belongs to the
+// module containing dir.
+func moduleDir(path, dir string) (string, bool) {
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			module := modulePath(data)
+			rest, found := strings.CutPrefix(path, module)
+			if module == "" || !found || (rest != "" && rest[0] != '/') {
+				return "", false
+			}
+			return filepath.Join(dir, filepath.FromSlash(rest)), true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// modulePath returns the path in a go.mod file's module directive.
+func modulePath(gomod []byte) string {
+	for line := range strings.Lines(string(gomod)) {
+		line, _, _ = strings.Cut(line, "//")
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t' && rest[0] != '"') {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(rest); err == nil {
+			rest = unquoted
+		}
+		return rest
+	}
+	return ""
+}
+
+// isType reports whether fun, the function of a call, names string or a
+// type declared in p or in a package p imports, making the call a
+// conversion.
+func (x *extractor) isType(p *pkg, f *ast.File, fun ast.Expr) bool {
+	switch t := fun.(type) {
+	case *ast.ParenExpr:
+		return x.isType(p, f, t.X)
+	case *ast.Ident:
+		return t.Name == "string" || p.types[t.Name]
+	case *ast.SelectorExpr:
+		qualifier, ok := t.X.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		imported, err := x.importedPkg(p, f, qualifier.Name)
+		return err == nil && imported.types[t.Sel.Name]
+	}
+	return false
+}
+
+// eval resolves expr to a constant string.
+func (x *extractor) eval(p *pkg, f *ast.File, expr ast.Expr, depth int) (string, error) {
+	if depth > 32 {
+		return "", fmt.Errorf("constant nesting too deep")
+	}
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", fmt.Errorf("not a string literal")
+		}
+		return strconv.Unquote(e.Value)
+	case *ast.ParenExpr:
+		return x.eval(p, f, e.X, depth+1)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", fmt.Errorf("unsupported operator %s", e.Op)
+		}
+		left, err := x.eval(p, f, e.X, depth+1)
+		if err != nil {
+			return "", err
+		}
+		right, err := x.eval(p, f, e.Y, depth+1)
+		return left + right, err
+	case *ast.CallExpr:
+		// Conversions such as ErrorTypeURI("...")
+		if len(e.Args) != 1 || !x.isType(p, f, e.Fun) {
+			return "", fmt.Errorf("not a constant expression")
+		}
+		return x.eval(p, f, e.Args[0], depth+1)
+	case *ast.Ident:
+		value, ok := p.consts[e.Name]
+		if !ok {
+			return "", fmt.Errorf("%s is not a package-level constant", e.Name)
+		}
+		return x.eval(p, x.fileOf(p, value), value, depth+1)
+	case *ast.SelectorExpr:
+		qualifier, ok := e.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("not a constant expression")
+		}
+		imported, err := x.importedPkg(p, f, qualifier.Name)
+		if err != nil {
+			return "", err
+		}
+		return x.eval(imported, f, &ast.Ident{Name: e.Sel.Name}, depth+1)
+	}
+	return "", fmt.Errorf("not a constant expression")
+}
+
+// fileOf returns the file in p containing node, which determines the
+// imports in scope when evaluating it.
+func (x *extractor) fileOf(p *pkg, node ast.Node) *ast.File {
+	for _, f := range p.files {
+		if f.FileStart <= node.Pos() && node.Pos() < f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+func (x *extractor) warn(pos token.Pos, format string, args ...any) {
+	x.warnings = append(x.warnings, fmt.Errorf("%s: %s", x.fset.Position(pos), fmt.Sprintf(format, args...)))
+}
+
+func (x *extractor) add(units map[rfc9457.MessageKey]rfc9457.TranslationUnit, key rfc9457.MessageKey, source string) {
+	if key.Type == "" || key.MessageID == "" || source == "" {
+		return
+	}
+	units[key] = rfc9457.TranslationUnit{Key: key, Source: source}
+}
+
+func (x *extractor) scanFile(p *pkg, f *ast.File) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CompositeLit:
+			x.scanCompositeLit(p, f, node)
+		case *ast.CallExpr:
+			x.scanAddMessage(p, f, node)
+		}
+		return true
+	})
+}
+
+// scanCompositeLit extracts titles from ErrorTypeDefinition and ResponseArgs
+// literals, and the Detail of ResponseArgs literals that name a MessageID.
+func (x *extractor) scanCompositeLit(p *pkg, f *ast.File, lit *ast.CompositeLit) {
+	if elemType, ok := lit.Type.(*ast.ArrayType); ok {
+		// Elements of []ErrorTypeDefinition{{...}} elide their type
+		for _, elt := range lit.Elts {
+			inner, ok := elt.(*ast.CompositeLit)
+			if ok && inner.Type == nil {
+				x.scanTypedLit(p, f, inner, typeName(elemType.Elt))
+			}
+		}
+		return
+	}
+	x.scanTypedLit(p, f, lit, typeName(lit.Type))
+}
+
+func (x *extractor) scanTypedLit(p *pkg, f *ast.File, lit *ast.CompositeLit, name string) {
+	if name != "ErrorTypeDefinition" && name != "ResponseArgs" {
+		return
+	}
+	fields := x.literalFields(p, f, lit, "Type", "Title", "Detail", "MessageID")
+	typ := rfc9457.ErrorTypeURI(fields["Type"])
+	if typ == "" {
+		return
+	}
+	title := rfc9457.MessageKey{Type: typ, MessageID: rfc9457.TitleMessageID}
+	if name == "ErrorTypeDefinition" {
+		x.add(x.units, title, fields["Title"])
+		return
+	}
+	x.add(x.fallbackTitles, title, fields["Title"])
+	x.add(x.units, rfc9457.MessageKey{Type: typ, MessageID: fields["MessageID"]}, fields["Detail"])
+}
+
+// scanAddMessage extracts default-language templates from
+// catalog.AddMessage(lang, MessageKey{...}, template) calls.
+func (x *extractor) scanAddMessage(p *pkg, f *ast.File, call *ast.CallExpr) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "AddMessage" || len(call.Args) != 3 {
+		return
+	}
+	lang, err := x.eval(p, f, call.Args[0], 0)
+	if err != nil || lang != rfc9457.DefaultLanguage {
+		return
+	}
+	lit, ok := call.Args[1].(*ast.CompositeLit)
+	if !ok || typeName(lit.Type) != "MessageKey" {
+		return
+	}
+	fields := x.literalFields(p, f, lit, "Type", "MessageID")
+	template, err := x.eval(p, f, call.Args[2], 0)
+	if err != nil {
+		x.warn(call.Args[2].Pos(), "message template: %v", err)
+		return
+	}
+	x.add(x.units, rfc9457.MessageKey{Type: rfc9457.ErrorTypeURI(fields["Type"]), MessageID: fields["MessageID"]}, template)
+}
+
+// literalFields evaluates the named keyed fields of lit, omitting those that
+// are absent or not constant.
+func (x *extractor) literalFields(p *pkg, f *ast.File, lit *ast.CompositeLit, names ...string) map[string]string {
+	fields := make(map[string]string, len(names))
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || !slices.Contains(names, key.Name) {
+			continue
+		}
+		value, err := x.eval(p, f, kv.Value, 0)
+		if err != nil {
+			if key.Name == "Type" || key.Name == "MessageID" {
+				x.warn(kv.Value.Pos(), "%s: %v", key.Name, err)
+			}
+			continue
+		}
+		fields[key.Name] = value
+	}
+	return fields
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package msgextract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func TestExtract(t *testing.T) {
+	units, warnings, err := Extract([]string{"testdata/app/handlers"})
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	got := make(map[rfc9457.MessageKey]string)
+	for _, unit := range units {
+		got[unit.Key] = unit.Source
+	}
+
+	const notFound = "https://example.com/errors/not-found"
+	tests := []struct {
+		name      string
+		typ       rfc9457.ErrorTypeURI
+		messageID string
+		want      string
+	}{
+		{"constant_across_packages", notFound, rfc9457.TitleMessageID, "Not Found"},
+		{"concatenated_detail", notFound, "missing", "No user {id}"},
+		{"elided_element_type", "https://example.com/errors/conflict", rfc9457.TitleMessageID, "Conflict"},
+		{"type_conversion", "https://example.com/errors/converted", rfc9457.TitleMessageID, "Converted"},
+		{"add_message", notFound, "gone", "User {id} is gone"},
+		{"function_call_not_constant", notFound, "shout", ""},
+		{"fmt_call_not_constant", notFound, "sprint", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := rfc9457.MessageKey{Type: tt.typ, MessageID: tt.messageID}
+			if got[key] != tt.want {
+				t.Errorf("%v: got %q, want %q", key, got[key], tt.want)
+			}
+		})
+	}
+	if len(units) != 5 {
+		t.Errorf("Units: got %d, want 5: %v", len(units), units)
+	}
+	for _, w := range warnings {
+		if !strings.Contains(w.Error(), "not a constant") {
+			t.Errorf("Unexpected warning: %v", w)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package errtypes
+
+type ErrorTypeURI string
+
+const base ErrorTypeURI = "https://example.com/errors"
+
+const (
+	NotFound ErrorTypeURI = base + "/not-found"
+	Conflict              = base + "/conflict"
+)
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"app/errtypes"
+)
+
+const detailPrefix = "No user "
+
+var definitions = []ErrorTypeDefinition{
+	{Type: errtypes.Conflict, Title: "Conflict"},
+}
+
+func problems(c catalog, id string) []ResponseArgs {
+	c.AddMessage("en", MessageKey{Type: errtypes.NotFound, MessageID: "gone"}, "User {id} is gone")
+	c.AddMessage("fr", MessageKey{Type: errtypes.NotFound, MessageID: "gone"}, "Utilisateur {id} parti")
+	return []ResponseArgs{
+		{Type: errtypes.NotFound, Title: "Not Found", MessageID: "missing", Detail: detailPrefix + "{id}"},
+		{Type: errtypes.ErrorTypeURI("https://example.com/errors/converted"), Title: "Converted"},
+		{Type: errtypes.NotFound, MessageID: "shout", Detail: strings.ToUpper("no user")},
+		{Type: errtypes.NotFound, MessageID: "sprint", Detail: fmt.Sprint(id)},
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import "app/errtypes"
+
+type ErrorTypeDefinition struct {
+	Type  errtypes.ErrorTypeURI
+	Title string
+}
+
+type ResponseArgs struct {
+	Type      errtypes.ErrorTypeURI
+	Title     string
+	Detail    string
+	MessageID string
+}
+
+type MessageKey struct {
+	Type      errtypes.ErrorTypeURI
+	MessageID string
+}
+
+type catalog struct{}
+
+func (catalog) AddMessage(lang string, key MessageKey, template string) {}
@@ -0,0 +1,75 @@
+package rfc9457
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ Observer = (*LogObserver)(nil)
+
+// DefaultProblemLogLevel logs 5xx problems at Error and others at Info.
+func DefaultProblemLogLevel(status int) slog.Level {
+	if status >= 500 {
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// LogObserverArgs configures NewLogObserver.
+type LogObserverArgs struct {
+	// Level chooses the level by status; DefaultProblemLogLevel when nil.
+	Level func(status int) slog.Level
+	// SampleEvery logs one in every n 4xx problems of a type. Types not
+	// listed use DefaultSampleEvery; 0 and 1 log every problem. 5xx
+	// problems are never sampled.
+	SampleEvery        map[ErrorTypeURI]int
+	DefaultSampleEvery int
+}
+
+// LogObserver is an Observer that logs each written problem through
+// LoggerFrom(ctx, WriteComponent). Register it with AddObserver.
+type LogObserver struct {
+	args   LogObserverArgs
+	counts sync.Map // ErrorTypeURI -> *atomic.Int64
+}
+
+func NewLogObserver(args LogObserverArgs) *LogObserver {
+	if args.Level == nil {
+		args.Level = DefaultProblemLogLevel
+	}
+	return &LogObserver{args: args}
+}
+
+func (*LogObserver) ProblemConstructed(*Response) {}
+
+func (*LogObserver) ProblemDecoded(*Response) {}
+
+func (*LogObserver) ProblemDecodeFailed(error) {}
+
+func (h *LogObserver) ProblemWritten(ctx context.Context, r *Response, status int, _ time.Duration) {
+	every := h.sampleEvery(r.Type, status)
+	if every > 1 {
+		counter, _ := h.counts.LoadOrStore(r.Type, new(atomic.Int64))
+		if (counter.(*atomic.Int64).Add(1)-1)%int64(every) != 0 {
+			return
+		}
+	}
+	args := []any{"problem", r, "http_status", status}
+	if every > 1 {
+		args = append(args, "sampled_every", every)
+	}
+	LoggerFrom(ctx, WriteComponent).Log(ctx, h.args.Level(status), "Problem written", args...)
+}
+
+func (h *LogObserver) sampleEvery(t ErrorTypeURI, status int) int {
+	if status < 400 || status > 499 {
+		return 1
+	}
+	if n, ok := h.args.SampleEvery[t]; ok {
+		return n
+	}
+	return h.args.DefaultSampleEvery
+}
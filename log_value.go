@@ -0,0 +1,61 @@
+package rfc9457
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+var _ slog.LogValuer = (*Response)(nil)
+
+// LogValue implements slog.LogValuer, logging r as a group of its members.
+// Extension members are grouped under "extensions" and a captured stack
+// under "stack". The installed Scrubber, if any, is applied first.
+func (r *Response) LogValue() slog.Value {
+	if s := GetScrubber(); s != nil {
+		r, _ = s.scrub(r, false)
+	}
+	attrs := []slog.Attr{
+		slog.String("type", string(r.Type)),
+		slog.String("title", r.Title),
+		slog.Int("status", r.Status),
+	}
+	if r.Detail != "" {
+		attrs = append(attrs, slog.String("detail", r.Detail))
+	}
+	if r.Instance != "" {
+		attrs = append(attrs, slog.String("instance", r.Instance))
+	}
+	if r.OccurrenceID != "" {
+		attrs = append(attrs, slog.String("occurrence_id", r.OccurrenceID))
+	}
+	if ext := r.extensionAttrs(); len(ext) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "extensions", Value: slog.GroupValue(ext...)})
+	}
+	if len(r.stack) > 0 {
+		attrs = append(attrs, slog.Any("stack", r.stack))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// extensionAttrs returns the members of all extensions in order, through
+// their JSON form. Extensions that are not JSON objects are logged by index.
+func (r *Response) extensionAttrs() (attrs []slog.Attr) {
+	for i, ext := range r.Extensions {
+		var members map[string]any
+		data, err := json.Marshal(ext)
+		if err == nil {
+			err = json.Unmarshal(data, &members)
+		}
+		if err != nil || members == nil {
+			attrs = append(attrs, slog.Any(strconv.Itoa(i), ext))
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(members)) {
+			attrs = append(attrs, slog.Any(name, members[name]))
+		}
+	}
+	return attrs
+}
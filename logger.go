@@ -1,23 +1,120 @@
 package rfc9457
 
 import (
+	"context"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 )
 
-var logger *slog.Logger
+// DiscardLogger drops everything; pass it to SetLogger to silence the
+// package.
+var DiscardLogger = slog.New(slog.DiscardHandler)
 
+var logger atomic.Pointer[slog.Logger]
+
+// Logger returns the logger set with SetLogger, or slog.Default() if none
+// was set.
 func Logger() *slog.Logger {
-	EnsureLogger()
-	return logger
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
 }
 
+// SetLogger sets the package logger; nil restores the slog.Default()
+// fallback.
 func SetLogger(l *slog.Logger) {
-	logger = l
+	logger.Store(l)
 }
 
+// EnsureLogger returns Logger().
+//
+// Deprecated: a logger is no longer required; Logger falls back to
+// slog.Default().
 func EnsureLogger() *slog.Logger {
-	if logger == nil {
-		panic("Must call rfc9457.SetLogger() with a *slog.Logger before reaching this check.")
+	return Logger()
+}
+
+// LogComponent names a part of the package that logs, so its output can be
+// routed separately with SetComponentLogger.
+type LogComponent string
+
+const (
+	// WriteComponent logs from Response.Write and LogObserver.
+	WriteComponent LogComponent = "write"
+	// DecodeComponent logs from Response.UnmarshalJSON.
+	DecodeComponent LogComponent = "decode"
+	// ClientComponent logs from Transport and RetryClient.
+	ClientComponent LogComponent = "client"
+	// OccurrenceComponent logs from occurrence stores and OccurrenceHandler.
+	OccurrenceComponent LogComponent = "occurrence"
+	// HandlerComponent logs from TimeoutHandler.
+	HandlerComponent LogComponent = "handler"
+)
+
+var componentLoggers sync.Map // LogComponent -> *slog.Logger
+
+// SetComponentLogger routes the logging of component c to l; nil routes it
+// back to Logger().
+func SetComponentLogger(c LogComponent, l *slog.Logger) {
+	if l == nil {
+		componentLoggers.Delete(c)
+		return
+	}
+	componentLoggers.Store(c, l)
+}
+
+// ComponentLogger returns the logger set for c, or Logger().
+func ComponentLogger(c LogComponent) *slog.Logger {
+	if l, ok := componentLoggers.Load(c); ok {
+		return l.(*slog.Logger)
 	}
-	return logger
+	return Logger()
+}
+
+type loggerContextKey struct{}
+
+// WithLogger returns a context carrying l, which the package uses in
+// preference to component and package loggers for work done on behalf of
+// that context, such as writing a problem for a request.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+// LoggerFrom returns the logger carried by ctx, or ComponentLogger(c).
+func LoggerFrom(ctx context.Context, c LogComponent) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok && l != nil {
+			return l
+		}
+	}
+	return ComponentLogger(c)
+}
+
+// DecodeFailurePolicy decides what Response.UnmarshalJSON logs about
+// extensions it cannot decode into a registered type.
+type DecodeFailurePolicy int
+
+const (
+	// LogDecodeFailures logs extensions that are not JSON objects at Warn
+	// and extensions kept as map[string]any at Debug, without their
+	// content. This is the default.
+	LogDecodeFailures DecodeFailurePolicy = iota
+	// LogDecodeFailuresWithData also logs the extension's raw JSON, which
+	// may contain client-supplied or sensitive data.
+	LogDecodeFailuresWithData
+	// SilenceDecodeFailures logs nothing.
+	SilenceDecodeFailures
+)
+
+var decodeFailurePolicy atomic.Int32
+
+// SetDecodeFailurePolicy sets what is logged when extensions fail to decode.
+func SetDecodeFailurePolicy(p DecodeFailurePolicy) {
+	decodeFailurePolicy.Store(int32(p))
+}
+
+func GetDecodeFailurePolicy() DecodeFailurePolicy {
+	return DecodeFailurePolicy(decodeFailurePolicy.Load())
 }
@@ -0,0 +1,297 @@
+package rfc9457
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TitleMessageID is the message ID under which a type's localized title is
+// stored in a MessageCatalog.
+const TitleMessageID = "title"
+
+// DefaultLanguage is the language of titles and details as written in code.
+const DefaultLanguage = "en"
+
+// MessageKey identifies a translatable message: a problem type plus a
+// message ID, e.g. {NoResultsErrorType, "title"}.
+type MessageKey struct {
+	Type      ErrorTypeURI
+	MessageID string
+}
+
+// MessageCatalog holds per-language message templates. Templates may contain
+// {name} placeholders which are filled from ResponseArgs.MessageArgs.
+type MessageCatalog struct {
+	mu              sync.RWMutex
+	defaultLanguage string
+	messages        map[string]map[MessageKey]string
+	fallbacks       map[string][]string
+}
+
+// NewMessageCatalog creates an empty catalog whose final fallback is
+// defaultLanguage (DefaultLanguage if empty).
+func NewMessageCatalog(defaultLanguage string) *MessageCatalog {
+	if defaultLanguage == "" {
+		defaultLanguage = DefaultLanguage
+	}
+	return &MessageCatalog{
+		defaultLanguage: normalizeLanguage(defaultLanguage),
+		messages:        make(map[string]map[MessageKey]string),
+		fallbacks:       make(map[string][]string),
+	}
+}
+
+func (c *MessageCatalog) DefaultLanguage() string {
+	return c.defaultLanguage
+}
+
+// AddMessage registers the template for key in lang.
+func (c *MessageCatalog) AddMessage(lang string, key MessageKey, template string) {
+	lang = normalizeLanguage(lang)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	msgs, ok := c.messages[lang]
+	if !ok {
+		msgs = make(map[MessageKey]string)
+		c.messages[lang] = msgs
+	}
+	msgs[key] = template
+}
+
+// SetFallbacks sets the languages tried, in order, after lang and before the
+// default language, e.g. SetFallbacks("pt-BR", "pt-PT").
+func (c *MessageCatalog) SetFallbacks(lang string, fallbacks ...string) {
+	normalized := make([]string, len(fallbacks))
+	for i, fb := range fallbacks {
+		normalized[i] = normalizeLanguage(fb)
+	}
+	c.mu.Lock()
+	c.fallbacks[normalizeLanguage(lang)] = normalized
+	c.mu.Unlock()
+}
+
+// Languages returns the languages that have at least one message, sorted.
+func (c *MessageCatalog) Languages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	langs := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Keys returns every key that has a message in any language.
+func (c *MessageCatalog) Keys() []MessageKey {
+	c.mu.RLock()
+	seen := make(map[MessageKey]struct{})
+	for _, msgs := range c.messages {
+		for key := range msgs {
+			seen[key] = struct{}{}
+		}
+	}
+	c.mu.RUnlock()
+	keys := make([]MessageKey, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compareMessageKeys)
+	return keys
+}
+
+func compareMessageKeys(a, b MessageKey) int {
+	return cmp.Or(
+		strings.Compare(string(a.Type), string(b.Type)),
+		strings.Compare(a.MessageID, b.MessageID),
+	)
+}
+
+// Message returns the template for key in lang, following lang's fallback
+// chain. resolved is the language the template was found in.
+func (c *MessageCatalog) Message(lang string, key MessageKey) (template, resolved string, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, candidate := range c.chain(normalizeLanguage(lang), true) {
+		template, ok = c.messages[candidate][key]
+		if ok {
+			resolved = candidate
+			break
+		}
+	}
+	return template, resolved, ok
+}
+
+// Render looks up key like Message and fills its placeholders from args.
+func (c *MessageCatalog) Render(lang string, key MessageKey, args map[string]any) (msg, resolved string, ok bool) {
+	msg, resolved, ok = c.Message(lang, key)
+	if ok {
+		msg = RenderMessage(msg, args)
+	}
+	return msg, resolved, ok
+}
+
+// chain returns lang, its configured fallbacks, its truncated parents
+// (zh-Hant-TW → zh-Hant → zh) and optionally the default language, without
+// duplicates. Caller must hold c.mu.
+func (c *MessageCatalog) chain(lang string, withDefault bool) (langs []string) {
+	add := func(l string) {
+		if l != "" && !slices.Contains(langs, l) {
+			langs = append(langs, l)
+		}
+	}
+	for l := lang; l != ""; l = parentLanguage(l) {
+		add(l)
+		for _, fb := range c.fallbacks[l] {
+			add(fb)
+		}
+	}
+	if withDefault {
+		add(c.defaultLanguage)
+	}
+	return langs
+}
+
+func (c *MessageCatalog) hasLanguage(lang string) bool {
+	return len(c.messages[lang]) > 0
+}
+
+// Negotiate picks the best catalog language for an Accept-Language header
+// value, returning the default language when nothing acceptable matches.
+func (c *MessageCatalog) Negotiate(acceptLanguage string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, lang := range ParseAcceptLanguage(acceptLanguage) {
+		if lang == "*" {
+			break
+		}
+		for _, candidate := range c.chain(lang, false) {
+			if candidate == c.defaultLanguage || c.hasLanguage(candidate) {
+				return candidate
+			}
+		}
+	}
+	return c.defaultLanguage
+}
+
+// ParseAcceptLanguage returns the language ranges of an Accept-Language
+// header ordered by descending quality, omitting those with q=0.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var ranges []weighted
+	for part := range strings.SplitSeq(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = normalizeLanguage(lang)
+		if lang == "" {
+			continue
+		}
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		ranges = append(ranges, weighted{lang: lang, q: q})
+	}
+	slices.SortStableFunc(ranges, func(a, b weighted) int {
+		return cmp.Compare(b.q, a.q)
+	})
+	langs := make([]string, len(ranges))
+	for i, r := range ranges {
+		langs[i] = r.lang
+	}
+	return langs
+}
+
+// RenderMessage replaces each {name} in template with fmt.Sprint(args[name]).
+// Placeholders with no matching arg are left as-is.
+func RenderMessage(template string, args map[string]any) string {
+	if len(args) == 0 || !strings.Contains(template, "{") {
+		return template
+	}
+	var sb strings.Builder
+	for {
+		open := strings.IndexByte(template, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(template[open:], '}')
+		if end < 0 {
+			break
+		}
+		end += open
+		sb.WriteString(template[:open])
+		value, ok := args[template[open+1:end]]
+		if ok {
+			sb.WriteString(fmt.Sprint(value))
+		} else {
+			sb.WriteString(template[open : end+1])
+		}
+		template = template[end+1:]
+	}
+	sb.WriteString(template)
+	return sb.String()
+}
+
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+func parentLanguage(lang string) string {
+	i := strings.LastIndexByte(lang, '-')
+	if i < 0 {
+		return ""
+	}
+	return lang[:i]
+}
+
+var messageCatalog struct {
+	sync.RWMutex
+	c *MessageCatalog
+}
+
+// SetMessageCatalog installs the catalog Response.Write localizes with. It is
+// only consulted for responses created with ResponseArgs.Request set, since
+// that is where Accept-Language comes from. Pass nil to disable.
+func SetMessageCatalog(c *MessageCatalog) {
+	messageCatalog.Lock()
+	messageCatalog.c = c
+	messageCatalog.Unlock()
+}
+
+// GetMessageCatalog returns the catalog installed by SetMessageCatalog, or nil.
+func GetMessageCatalog() *MessageCatalog {
+	messageCatalog.RLock()
+	defer messageCatalog.RUnlock()
+	return messageCatalog.c
+}
+
+// formatLanguageTag restores conventional casing to a normalized tag for
+// use in Content-Language, e.g. "zh-hant-tw" → "zh-Hant-TW".
+func formatLanguageTag(lang string) string {
+	parts := strings.Split(lang, "-")
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,355 @@
+package rfc9457
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ Observer = (*ExpvarObserver)(nil)
+
+// DefaultExpvarName is the expvar name and Prometheus metric prefix used when
+// ExpvarObserverArgs.Name is empty.
+const DefaultExpvarName = "rfc9457"
+
+// DefaultLatencyBuckets are the upper bounds of the write latency histogram
+// used when ExpvarObserverArgs.LatencyBuckets is empty.
+var DefaultLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+type ExpvarObserverArgs struct {
+	// Name is the expvar name the metrics are published under, and the
+	// prefix of their Prometheus names; DefaultExpvarName when empty. It
+	// must be a valid Prometheus metric name.
+	Name string
+	// LatencyBuckets are the ascending upper bounds of the write latency
+	// histogram; DefaultLatencyBuckets when empty.
+	LatencyBuckets []time.Duration
+}
+
+// ExpvarObserver is an Observer that counts problems by event, type and
+// status, and keeps a histogram of Response.Write latency. Its metrics,
+// along with the ScrubCounts of the installed Scrubber, are published with
+// expvar as:
+//
+//	{
+//	  "constructed": {"<type>": {"<status>": n}},
+//	  "written": {...},
+//	  "decoded": {...},
+//	  "decode_failures": n,
+//	  "write_latency": {"count": n, "sum_seconds": s, "buckets": {"<le>": n}},
+//	  "scrubbed": {"<rule>": {"<type>": n}}
+//	}
+//
+// and served in the Prometheus text format by PrometheusHandler. Types that
+// are neither registered nor about:blank, MultipleProblemsErrorType or
+// TimeoutErrorType are counted as "other", so that types received from
+// remote servers cannot create series without bound.
+type ExpvarObserver struct {
+	name    string
+	mu      sync.Mutex
+	counts  map[problemCounterKey]int64
+	failed  atomic.Int64
+	latency latencyHistogram
+}
+
+type problemEvent string
+
+const (
+	constructedEvent problemEvent = "constructed"
+	writtenEvent     problemEvent = "written"
+	decodedEvent     problemEvent = "decoded"
+)
+
+var problemEvents = []problemEvent{constructedEvent, writtenEvent, decodedEvent}
+
+// otherMetricType is the type unregistered problem types are counted as.
+const otherMetricType ErrorTypeURI = "other"
+
+type problemCounterKey struct {
+	event  problemEvent
+	typ    ErrorTypeURI
+	status int
+}
+
+// NewExpvarObserver creates an ExpvarObserver and publishes it with expvar.
+// It returns an error if args.Name is already published. Register it with
+// AddObserver.
+func NewExpvarObserver(args ExpvarObserverArgs) (o *ExpvarObserver, err error) {
+	if args.Name == "" {
+		args.Name = DefaultExpvarName
+	}
+	if len(args.LatencyBuckets) == 0 {
+		args.LatencyBuckets = DefaultLatencyBuckets
+	}
+	if !slices.IsSorted(args.LatencyBuckets) {
+		err = fmt.Errorf("latency buckets are not in ascending order: %v", args.LatencyBuckets)
+		goto end
+	}
+	if expvar.Get(args.Name) != nil {
+		err = fmt.Errorf("expvar %q is already published", args.Name)
+		goto end
+	}
+	o = &ExpvarObserver{
+		name:   args.Name,
+		counts: make(map[problemCounterKey]int64),
+		latency: latencyHistogram{
+			bounds: args.LatencyBuckets,
+			counts: make([]atomic.Int64, len(args.LatencyBuckets)+1),
+		},
+	}
+	expvar.Publish(args.Name, expvar.Func(o.expvarValue))
+end:
+	return o, err
+}
+
+func (o *ExpvarObserver) ProblemConstructed(r *Response) {
+	o.count(constructedEvent, r.Type, r.Status)
+}
+
+func (o *ExpvarObserver) ProblemWritten(_ context.Context, r *Response, status int, elapsed time.Duration) {
+	o.count(writtenEvent, r.Type, status)
+	o.latency.observe(elapsed)
+}
+
+func (o *ExpvarObserver) ProblemDecoded(r *Response) {
+	o.count(decodedEvent, r.Type, r.Status)
+}
+
+func (o *ExpvarObserver) ProblemDecodeFailed(error) {
+	o.failed.Add(1)
+}
+
+func (o *ExpvarObserver) count(event problemEvent, typ ErrorTypeURI, status int) {
+	typ = metricType(typ)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.counts[problemCounterKey{event: event, typ: typ, status: status}]++
+}
+
+// metricType returns the type typ is counted as.
+func metricType(typ ErrorTypeURI) ErrorTypeURI {
+	switch typ {
+	case "":
+		return AboutBlankErrorType
+	case AboutBlankErrorType, MultipleProblemsErrorType, TimeoutErrorType:
+		return typ
+	}
+	if _, ok := LookupErrorType(typ); ok {
+		return typ
+	}
+	return otherMetricType
+}
+
+// Count returns how many problems of type typ and status were seen for
+// event, which is "constructed", "written" or "decoded". Unregistered types
+// are counted together under the type "other".
+func (o *ExpvarObserver) Count(event string, typ ErrorTypeURI, status int) int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.counts[problemCounterKey{event: problemEvent(event), typ: typ, status: status}]
+}
+
+// DecodeFailures returns how many decode failures were seen.
+func (o *ExpvarObserver) DecodeFailures() int64 {
+	return o.failed.Load()
+}
+
+// sortedCounts returns a snapshot of the counters ordered by event, type and
+// status.
+func (o *ExpvarObserver) sortedCounts() []problemCounter {
+	o.mu.Lock()
+	counters := make([]problemCounter, 0, len(o.counts))
+	for key, n := range o.counts {
+		counters = append(counters, problemCounter{key, n})
+	}
+	o.mu.Unlock()
+	slices.SortFunc(counters, func(a, b problemCounter) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(problemEvents, a.event), slices.Index(problemEvents, b.event)),
+			cmp.Compare(a.typ, b.typ),
+			cmp.Compare(a.status, b.status),
+		)
+	})
+	return counters
+}
+
+type problemCounter struct {
+	problemCounterKey
+	n int64
+}
+
+func (o *ExpvarObserver) expvarValue() any {
+	value := map[string]any{
+		"decode_failures": o.failed.Load(),
+		"write_latency":   o.latency.expvarValue(),
+	}
+	for _, event := range problemEvents {
+		value[string(event)] = map[ErrorTypeURI]map[string]int64{}
+	}
+	for _, c := range o.sortedCounts() {
+		byType := value[string(c.event)].(map[ErrorTypeURI]map[string]int64)
+		if byType[c.typ] == nil {
+			byType[c.typ] = make(map[string]int64)
+		}
+		byType[c.typ][strconv.Itoa(c.status)] = c.n
+	}
+	scrubbed := map[string]map[ErrorTypeURI]int64{}
+	for _, c := range scrubCounts() {
+		if scrubbed[c.Rule] == nil {
+			scrubbed[c.Rule] = make(map[ErrorTypeURI]int64)
+		}
+		scrubbed[c.Rule][c.Type] = c.Count
+	}
+	value["scrubbed"] = scrubbed
+	return value
+}
+
+// scrubCounts returns the ScrubCounts of the installed Scrubber, if any,
+// with unregistered types counted together as for problems.
+func scrubCounts() []ScrubCount {
+	s := GetScrubber()
+	if s == nil {
+		return nil
+	}
+	folded := make(map[scrubKey]int64)
+	for _, c := range s.ScrubCounts() {
+		folded[scrubKey{rule: c.Rule, typ: metricType(c.Type)}] += c.Count
+	}
+	counts := make([]ScrubCount, 0, len(folded))
+	for key, n := range folded {
+		counts = append(counts, ScrubCount{Rule: key.rule, Type: key.typ, Count: n})
+	}
+	slices.SortFunc(counts, func(a, b ScrubCount) int {
+		return cmp.Or(cmp.Compare(a.Rule, b.Rule), cmp.Compare(a.Type, b.Type))
+	})
+	return counts
+}
+
+// String returns the metrics as expvar publishes them.
+func (o *ExpvarObserver) String() string {
+	data, _ := json.Marshal(o.expvarValue())
+	return string(data)
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format, named <name>_problems_<event>_total,
+// <name>_problem_decode_failures_total,
+// <name>_problem_write_duration_seconds and <name>_problem_scrubs_total.
+func (o *ExpvarObserver) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	counters := o.sortedCounts()
+	for _, event := range problemEvents {
+		metric := fmt.Sprintf("%s_problems_%s_total", o.name, event)
+		fmt.Fprintf(bw, "# HELP %s Problems %s, by type and status.\n", metric, event)
+		fmt.Fprintf(bw, "# TYPE %s counter\n", metric)
+		for _, c := range counters {
+			if c.event == event {
+				fmt.Fprintf(bw, "%s{type=%s,status=\"%d\"} %d\n", metric, prometheusLabel(string(c.typ)), c.status, c.n)
+			}
+		}
+	}
+	metric := o.name + "_problem_decode_failures_total"
+	fmt.Fprintf(bw, "# HELP %s Problem documents or extensions that failed to decode.\n", metric)
+	fmt.Fprintf(bw, "# TYPE %s counter\n", metric)
+	fmt.Fprintf(bw, "%s %d\n", metric, o.failed.Load())
+	o.latency.writePrometheus(bw, o.name+"_problem_write_duration_seconds")
+	metric = o.name + "_problem_scrubs_total"
+	fmt.Fprintf(bw, "# HELP %s Replacements made by Scrubber rules, by rule and type.\n", metric)
+	fmt.Fprintf(bw, "# TYPE %s counter\n", metric)
+	for _, c := range scrubCounts() {
+		fmt.Fprintf(bw, "%s{rule=%s,type=%s} %d\n", metric, prometheusLabel(c.Rule), prometheusLabel(string(c.Type)), c.Count)
+	}
+	return bw.Flush()
+}
+
+// PrometheusHandler serves WritePrometheus.
+func (o *ExpvarObserver) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		err := o.WritePrometheus(w)
+		if err != nil {
+			LoggerFrom(req.Context(), WriteComponent).Error("Failed to write metrics", "error", err)
+		}
+	})
+}
+
+func prometheusLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
+
+// latencyHistogram counts durations into buckets; counts has one more
+// element than bounds, for durations above the last bound.
+type latencyHistogram struct {
+	bounds []time.Duration
+	counts []atomic.Int64
+	sum    atomic.Int64 // nanoseconds
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	i, _ := slices.BinarySearch(h.bounds, d)
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// cumulative returns the count of durations at or below each bound,
+// followed by the total count.
+func (h *latencyHistogram) cumulative() []int64 {
+	counts := make([]int64, len(h.counts))
+	var total int64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+		counts[i] = total
+	}
+	return counts
+}
+
+func (h *latencyHistogram) expvarValue() any {
+	counts := h.cumulative()
+	buckets := make(map[string]int64, len(counts))
+	for i, bound := range h.bounds {
+		buckets[formatSeconds(bound)] = counts[i]
+	}
+	buckets["+Inf"] = counts[len(counts)-1]
+	return map[string]any{
+		"count":       counts[len(counts)-1],
+		"sum_seconds": time.Duration(h.sum.Load()).Seconds(),
+		"buckets":     buckets,
+	}
+}
+
+func (h *latencyHistogram) writePrometheus(w io.Writer, metric string) {
+	counts := h.cumulative()
+	fmt.Fprintf(w, "# HELP %s Time taken by Response.Write.\n", metric)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", metric)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", metric, formatSeconds(bound), counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", metric, counts[len(counts)-1])
+	fmt.Fprintf(w, "%s_sum %s\n", metric, strconv.FormatFloat(time.Duration(h.sum.Load()).Seconds(), 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", metric, counts[len(counts)-1])
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
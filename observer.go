@@ -0,0 +1,84 @@
+package rfc9457
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Observer is notified of each problem the package constructs, writes and
+// decodes. Methods are called synchronously and must be safe for concurrent
+// use.
+type Observer interface {
+	// ProblemConstructed is called by NewResponse.
+	ProblemConstructed(r *Response)
+	// ProblemWritten is called by Response.Write, and by BatchResult.Write
+	// for each item problem, after the problem is sent with status. r is the
+	// problem as constructed, before localization and redaction; elapsed is
+	// the time Write took.
+	ProblemWritten(ctx context.Context, r *Response, status int, elapsed time.Duration)
+	// ProblemDecoded is called by ParseHTTPResponse, and so by Transport,
+	// for each problem decoded from a response body, and by
+	// ParseBatchResult for each item problem. Problems synthesized from
+	// other error bodies, and documents decoded directly with
+	// Response.UnmarshalJSON, are not reported.
+	ProblemDecoded(r *Response)
+	// ProblemDecodeFailed is called by ParseHTTPResponse and
+	// ParseBatchResult once for a problem document that cannot be decoded,
+	// and once for each extension of a decoded problem that cannot be.
+	// Response.UnmarshalJSON itself only logs extension failures.
+	ProblemDecodeFailed(err error)
+}
+
+// ProblemWrittenFunc adapts a function to an Observer that is notified only
+// of written problems.
+type ProblemWrittenFunc func(ctx context.Context, r *Response, status int, elapsed time.Duration)
+
+var _ Observer = ProblemWrittenFunc(nil)
+
+func (f ProblemWrittenFunc) ProblemWritten(ctx context.Context, r *Response, status int, elapsed time.Duration) {
+	f(ctx, r, status, elapsed)
+}
+
+func (ProblemWrittenFunc) ProblemConstructed(*Response) {}
+
+func (ProblemWrittenFunc) ProblemDecoded(*Response) {}
+
+func (ProblemWrittenFunc) ProblemDecodeFailed(error) {}
+
+var observers struct {
+	sync.RWMutex
+	list []Observer
+}
+
+// AddObserver registers o to be notified of problem events.
+func AddObserver(o Observer) {
+	observers.Lock()
+	defer observers.Unlock()
+	observers.list = append(observers.list, o)
+}
+
+// ResetObservers removes all observers added with AddObserver.
+func ResetObservers() {
+	observers.Lock()
+	defer observers.Unlock()
+	observers.list = nil
+}
+
+func notifyObservers(notify func(o Observer)) {
+	observers.RLock()
+	list := observers.list
+	observers.RUnlock()
+	for _, o := range list {
+		notify(o)
+	}
+}
+
+// notifyDecoded reports r, and any extensions of it that failed to decode,
+// as received by a client.
+func notifyDecoded(r *Response) {
+	for _, err := range r.decodeErrs {
+		notifyObservers(func(o Observer) { o.ProblemDecodeFailed(err) })
+	}
+	notifyObservers(func(o Observer) { o.ProblemDecoded(r) })
+}
@@ -0,0 +1,103 @@
+package rfc9457
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type OccurrenceHandlerArgs struct {
+	// Store is where occurrences are looked up.
+	Store OccurrenceStore
+	// Authorize reports whether the caller may see occurrence records, which
+	// include internal context. A nil Authorize denies every request.
+	Authorize func(req *http.Request) bool
+}
+
+// OccurrenceHandler serves GET {instance} for instances whose URL ends in the
+// occurrence ID, i.e. those produced by an InstanceGenerator configured with
+// an OccurrencesRootURI that routes to this handler.
+type OccurrenceHandler struct {
+	store     OccurrenceStore
+	authorize func(req *http.Request) bool
+}
+
+var _ http.Handler = (*OccurrenceHandler)(nil)
+
+// NewOccurrenceHandler returns an OccurrenceHandler, or an error if
+// args.Store is nil.
+func NewOccurrenceHandler(args OccurrenceHandlerArgs) (h *OccurrenceHandler, err error) {
+	if args.Store == nil {
+		err = errors.New("occurrence handler requires a store")
+		goto end
+	}
+	h = &OccurrenceHandler{
+		store:     args.Store,
+		authorize: args.Authorize,
+	}
+end:
+	return h, err
+}
+
+func (h *OccurrenceHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var id string
+	var rec OccurrenceRecord
+	var err error
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		h.writeProblem(w, req, MethodNotAllowedErrorType, "Method Not Allowed", http.StatusMethodNotAllowed,
+			"Occurrences can only be retrieved with GET")
+		goto end
+	}
+	if h.authorize == nil || !h.authorize(req) {
+		h.writeProblem(w, req, UnauthorizedErrorType, "Unauthorized", http.StatusUnauthorized,
+			"Not authorized to view problem occurrences")
+		goto end
+	}
+
+	id = req.URL.Path[strings.LastIndexByte(req.URL.Path, '/')+1:]
+	if id == "" {
+		h.writeProblem(w, req, InvalidURLFormatErrorType, "Invalid URL Format", http.StatusBadRequest,
+			"No occurrence ID in URL")
+		goto end
+	}
+
+	rec, err = h.store.LoadOccurrence(req.Context(), id)
+	if errors.Is(err, ErrOccurrenceNotFound) {
+		h.writeProblem(w, req, NoResultsErrorType, "Occurrence Not Found", http.StatusNotFound,
+			"No problem occurrence with ID '"+id+"'")
+		goto end
+	}
+	if err != nil {
+		LoggerFrom(req.Context(), OccurrenceComponent).Error("Failed to load problem occurrence", "occurrence_id", id, "error", err)
+		h.writeProblem(w, req, InternalServerErrorType, "Internal Server Error", http.StatusInternalServerError,
+			"Occurrence could not be loaded")
+		goto end
+	}
+
+	w.Header().Set("Content-Type", string(ApplicationJSON))
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodHead {
+		goto end
+	}
+	err = json.NewEncoder(w).Encode(rec)
+	if err != nil {
+		LoggerFrom(req.Context(), OccurrenceComponent).Error("Failed to write problem occurrence", "occurrence_id", id, "error", err)
+	}
+end:
+}
+
+func (h *OccurrenceHandler) writeProblem(w http.ResponseWriter, req *http.Request, typ ErrorTypeURI, title string, status int, detail string) {
+	err := NewResponse(ResponseArgs{
+		Type:     typ,
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: req.URL.Path,
+	}).Write(w)
+	if err != nil {
+		LoggerFrom(req.Context(), OccurrenceComponent).Error("Failed to write error response", "error", err)
+	}
+}
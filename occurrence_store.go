@@ -0,0 +1,215 @@
+package rfc9457
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrOccurrenceNotFound = errors.New("occurrence not found")
+
+// OccurrenceRecord is what an OccurrenceStore keeps for each written problem:
+// the unredacted original Response, before scrubbing and redaction, plus
+// internal context that is never sent to clients.
+type OccurrenceRecord struct {
+	ID       string         `json:"id"`
+	Instance string         `json:"instance"`
+	Time     time.Time      `json:"time"`
+	Response *Response      `json:"response"`
+	Context  map[string]any `json:"context,omitempty"`
+}
+
+// OccurrenceStore persists problem occurrences so their Instance URIs can be
+// dereferenced later.
+type OccurrenceStore interface {
+	SaveOccurrence(ctx context.Context, rec OccurrenceRecord) error
+	LoadOccurrence(ctx context.Context, id string) (OccurrenceRecord, error)
+}
+
+var occurrenceStore struct {
+	sync.RWMutex
+	s OccurrenceStore
+}
+
+// SetOccurrenceStore installs the store Response.Write records occurrences in.
+// Only responses with an OccurrenceID are recorded. Pass nil to disable.
+func SetOccurrenceStore(s OccurrenceStore) {
+	occurrenceStore.Lock()
+	occurrenceStore.s = s
+	occurrenceStore.Unlock()
+}
+
+// GetOccurrenceStore returns the store installed by SetOccurrenceStore, or nil.
+func GetOccurrenceStore() OccurrenceStore {
+	occurrenceStore.RLock()
+	defer occurrenceStore.RUnlock()
+	return occurrenceStore.s
+}
+
+// NewOccurrenceRecord builds the record for r as of now. The record holds a
+// copy of r's members and internal context, not r itself, so it neither
+// keeps r's request alive nor sees later changes to r.
+func NewOccurrenceRecord(r *Response) OccurrenceRecord {
+	return OccurrenceRecord{
+		ID:       r.OccurrenceID,
+		Instance: r.Instance,
+		Time:     time.Now().UTC(),
+		Response: &Response{
+			Type:         r.Type,
+			Title:        r.Title,
+			Status:       r.Status,
+			Detail:       r.Detail,
+			Instance:     r.Instance,
+			Extensions:   slices.Clone(r.Extensions),
+			OccurrenceID: r.OccurrenceID,
+		},
+		Context: maps.Clone(r.InternalContext()),
+	}
+}
+
+// MemoryOccurrenceStore keeps the most recent occurrences in a fixed-size
+// ring buffer; older occurrences are evicted as new ones are saved.
+type MemoryOccurrenceStore struct {
+	mu      sync.Mutex
+	records []OccurrenceRecord
+	index   map[string]int
+	next    int
+}
+
+var _ OccurrenceStore = (*MemoryOccurrenceStore)(nil)
+
+// DefaultMemoryOccurrenceCapacity is used when NewMemoryOccurrenceStore is
+// given a non-positive capacity.
+const DefaultMemoryOccurrenceCapacity = 1024
+
+func NewMemoryOccurrenceStore(capacity int) *MemoryOccurrenceStore {
+	if capacity <= 0 {
+		capacity = DefaultMemoryOccurrenceCapacity
+	}
+	return &MemoryOccurrenceStore{
+		records: make([]OccurrenceRecord, capacity),
+		index:   make(map[string]int, capacity),
+	}
+}
+
+func (s *MemoryOccurrenceStore) SaveOccurrence(_ context.Context, rec OccurrenceRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evicted := s.records[s.next]
+	if evicted.ID != "" && s.index[evicted.ID] == s.next {
+		delete(s.index, evicted.ID)
+	}
+	s.records[s.next] = rec
+	s.index[rec.ID] = s.next
+	s.next = (s.next + 1) % len(s.records)
+	return nil
+}
+
+func (s *MemoryOccurrenceStore) LoadOccurrence(_ context.Context, id string) (rec OccurrenceRecord, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[id]
+	if !ok {
+		err = ErrOccurrenceNotFound
+		goto end
+	}
+	rec = s.records[i]
+end:
+	return rec, err
+}
+
+// FileOccurrenceStore appends occurrences to a JSON Lines file, one record
+// per line. Lookups scan the file, so it suits support tooling rather than
+// hot paths.
+type FileOccurrenceStore struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+var _ OccurrenceStore = (*FileOccurrenceStore)(nil)
+
+// NewFileOccurrenceStore opens (creating if needed) the JSONL file at path
+// for appending. Call Close when done.
+func NewFileOccurrenceStore(path string) (*FileOccurrenceStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening occurrence store %s: %w", path, err)
+	}
+	return &FileOccurrenceStore{path: path, file: f}, nil
+}
+
+func (s *FileOccurrenceStore) SaveOccurrence(_ context.Context, rec OccurrenceRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding occurrence %s: %w", rec.ID, err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(line)
+	if err != nil {
+		return fmt.Errorf("writing occurrence %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// LoadOccurrence returns the last record saved with the given ID.
+func (s *FileOccurrenceStore) LoadOccurrence(ctx context.Context, id string) (rec OccurrenceRecord, err error) {
+	var f *os.File
+	var scanner *bufio.Scanner
+	var found bool
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err = os.Open(s.path)
+	if err != nil {
+		err = fmt.Errorf("opening occurrence store %s: %w", s.path, err)
+		goto end
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner = bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			goto end
+		}
+		var candidate OccurrenceRecord
+		if json.Unmarshal(scanner.Bytes(), &candidate) != nil {
+			continue
+		}
+		if candidate.ID == id {
+			rec = candidate
+			found = true
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		err = fmt.Errorf("reading occurrence store %s: %w", s.path, err)
+		goto end
+	}
+	if !found {
+		err = ErrOccurrenceNotFound
+	}
+end:
+	return rec, err
+}
+
+func (s *FileOccurrenceStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,178 @@
+package rfc9457
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WritePO writes tf as a gettext PO file, or a POT template when
+// tf.Language is empty. Each unit's MessageKey is carried in msgctxt.
+func WritePO(w io.Writer, tf TranslationFile) error {
+	bw := bufio.NewWriter(w)
+	header := "Content-Type: text/plain; charset=UTF-8\n"
+	if tf.Language != "" {
+		header = "Language: " + tf.Language + "\n" + header
+	}
+	bw.WriteString("msgid \"\"\n")
+	writePOString(bw, "msgstr", header)
+	for _, unit := range tf.Units {
+		bw.WriteString("\n")
+		if unit.Fuzzy {
+			bw.WriteString("#, fuzzy\n")
+		}
+		writePOString(bw, "msgctxt", translationUnitID(unit.Key))
+		writePOString(bw, "msgid", unit.Source)
+		writePOString(bw, "msgstr", unit.Target)
+	}
+	return bw.Flush()
+}
+
+// writePOString writes keyword and s, splitting after embedded newlines the
+// way gettext tools do.
+func writePOString(bw *bufio.Writer, keyword, s string) {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(bw, "%s %s\n", keyword, quotePO(s))
+		return
+	}
+	fmt.Fprintf(bw, "%s \"\"\n", keyword)
+	for _, line := range lines {
+		fmt.Fprintf(bw, "%s\n", quotePO(line))
+	}
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+func quotePO(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
+
+// ReadPO parses a gettext PO or POT file. Entries without a msgctxt (such as
+// the header) are not returned as units; the header's Language field sets
+// the file's language.
+func ReadPO(r io.Reader) (tf TranslationFile, err error) {
+	type poEntry struct {
+		fields map[string]string
+		fuzzy  bool
+	}
+	var entries []poEntry
+	var current poEntry
+	var field string
+	var lineNo int
+
+	flush := func() {
+		if current.fields != nil {
+			entries = append(entries, current)
+		}
+		current = poEntry{}
+		field = ""
+	}
+	// A comment, msgctxt or msgid after a msgid begins the next entry
+	inEntry := func() bool {
+		_, ok := current.fields["msgid"]
+		return ok
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "#"):
+			if inEntry() {
+				flush()
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				current.fuzzy = true
+			}
+		case strings.HasPrefix(line, `"`):
+			if field == "" {
+				err = fmt.Errorf("line %d: string continuation without keyword", lineNo)
+				goto end
+			}
+			var s string
+			s, err = unquotePO(line)
+			if err != nil {
+				err = fmt.Errorf("line %d: %w", lineNo, err)
+				goto end
+			}
+			current.fields[field] += s
+		default:
+			keyword, value, ok := strings.Cut(line, " ")
+			if !ok {
+				err = fmt.Errorf("line %d: expected keyword and string", lineNo)
+				goto end
+			}
+			if (keyword == "msgctxt" || keyword == "msgid") && inEntry() {
+				flush()
+			}
+			if keyword == "msgstr[0]" {
+				keyword = "msgstr"
+			}
+			if current.fields == nil {
+				current.fields = make(map[string]string)
+			}
+			var s string
+			s, err = unquotePO(strings.TrimSpace(value))
+			if err != nil {
+				err = fmt.Errorf("line %d: %w", lineNo, err)
+				goto end
+			}
+			field = keyword
+			current.fields[field] = s
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		goto end
+	}
+	flush()
+
+	for _, entry := range entries {
+		ctxt, hasCtxt := entry.fields["msgctxt"]
+		if !hasCtxt {
+			if entry.fields["msgid"] == "" {
+				tf.Language = poHeaderField(entry.fields["msgstr"], "Language")
+			}
+			continue
+		}
+		var key MessageKey
+		key, err = parseTranslationUnitID(ctxt)
+		if err != nil {
+			goto end
+		}
+		tf.Units = append(tf.Units, TranslationUnit{
+			Key:    key,
+			Source: entry.fields["msgid"],
+			Target: entry.fields["msgstr"],
+			Fuzzy:  entry.fuzzy,
+		})
+	}
+end:
+	return tf, err
+}
+
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected quoted string, got %s", s)
+	}
+	return strconv.Unquote(s)
+}
+
+func poHeaderField(header, name string) string {
+	for line := range strings.SplitSeq(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package rfc9457
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProblemErrorConstructor builds a domain error from a decoded problem. It
+// may return a sentinel such as ErrNotFound or a new error value; returning
+// nil leaves the problem unmapped.
+type ProblemErrorConstructor func(problem *Response) error
+
+// ProblemErrorRegistry maps problem type URIs to domain errors on the client.
+// Registrations match a problem's Type exactly or as a prefix, with the
+// longest matching registration winning.
+type ProblemErrorRegistry struct {
+	mu       sync.RWMutex
+	prefixes []ErrorTypeURI
+	ctors    map[ErrorTypeURI]ProblemErrorConstructor
+}
+
+func NewProblemErrorRegistry() *ProblemErrorRegistry {
+	return &ProblemErrorRegistry{
+		ctors: make(map[ErrorTypeURI]ProblemErrorConstructor),
+	}
+}
+
+// Register maps problems whose Type starts with prefix to errors built by ctor.
+func (reg *ProblemErrorRegistry) Register(prefix ErrorTypeURI, ctor ProblemErrorConstructor) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, exists := reg.ctors[prefix]; !exists {
+		reg.prefixes = append(reg.prefixes, prefix)
+		// Longest first so the first match is the most specific
+		sort.SliceStable(reg.prefixes, func(i, j int) bool {
+			return len(reg.prefixes[i]) > len(reg.prefixes[j])
+		})
+	}
+	reg.ctors[prefix] = ctor
+}
+
+// Map returns the domain error registered for problem's Type, wrapped in a
+// *MappedError so problem stays reachable through errors.As. problem itself
+// is returned when nothing matches.
+func (reg *ProblemErrorRegistry) Map(problem *Response) (err error) {
+	var ctor ProblemErrorConstructor
+	var mapped error
+
+	err = problem
+	reg.mu.RLock()
+	for _, prefix := range reg.prefixes {
+		if strings.HasPrefix(string(problem.Type), string(prefix)) {
+			ctor = reg.ctors[prefix]
+			break
+		}
+	}
+	reg.mu.RUnlock()
+	if ctor == nil {
+		goto end
+	}
+	mapped = ctor(problem)
+	if mapped == nil {
+		goto end
+	}
+	err = &MappedError{Err: mapped, Problem: problem}
+end:
+	return err
+}
+
+// MappedError pairs a domain error with the problem it was mapped from.
+// errors.Is and errors.As see both.
+type MappedError struct {
+	Err     error
+	Problem *Response
+}
+
+func (e *MappedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MappedError) Unwrap() []error {
+	return []error{e.Err, e.Problem}
+}
+
+var defaultProblemErrors = NewProblemErrorRegistry()
+
+// RegisterProblemError registers ctor in the default registry used by
+// MapProblemError and Transport.
+func RegisterProblemError(prefix ErrorTypeURI, ctor ProblemErrorConstructor) {
+	defaultProblemErrors.Register(prefix, ctor)
+}
+
+// MapProblemError maps problem using the default registry.
+func MapProblemError(problem *Response) error {
+	return defaultProblemErrors.Map(problem)
+}
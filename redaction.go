@@ -0,0 +1,125 @@
+package rfc9457
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactionProfile decides which internal details Response.Write withholds
+// from clients. Whatever is withheld is still logged in full.
+type RedactionProfile int
+
+const (
+	// DebugProfile sends problems unchanged. This is the default.
+	DebugProfile RedactionProfile = iota
+	// StagingProfile drops internal extensions (see InternalExtension).
+	StagingProfile
+	// ProductionProfile also replaces the Detail of types registered with
+	// SensitiveDetail by WithheldDetail, and gives 5xx problems the generic
+	// title for their status and no Detail.
+	ProductionProfile
+)
+
+// WithheldDetail replaces details withheld by ProductionProfile.
+const WithheldDetail = "Details of this problem are not available. Quote the problem instance when reporting it."
+
+var redactionProfiles = []string{"debug", "staging", "production"}
+
+func (p RedactionProfile) String() string {
+	if p < 0 || int(p) >= len(redactionProfiles) {
+		return fmt.Sprintf("RedactionProfile(%d)", int(p))
+	}
+	return redactionProfiles[p]
+}
+
+// ParseRedactionProfile parses "debug", "staging" or "production", as
+// typically read from the environment.
+func ParseRedactionProfile(s string) (RedactionProfile, error) {
+	for i, name := range redactionProfiles {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return RedactionProfile(i), nil
+		}
+	}
+	return DebugProfile, fmt.Errorf("unknown redaction profile %q; expected debug, staging or production", s)
+}
+
+var redactionProfile atomic.Int32
+
+// SetRedactionProfile sets the profile Response.Write applies.
+func SetRedactionProfile(p RedactionProfile) {
+	redactionProfile.Store(int32(p))
+}
+
+func GetRedactionProfile() RedactionProfile {
+	return RedactionProfile(redactionProfile.Load())
+}
+
+// InternalExtension is implemented by extensions that are only meant for
+// debugging and are dropped by StagingProfile and ProductionProfile.
+type InternalExtension interface {
+	InternalExtension()
+}
+
+// MarkInternal wraps ext so that it is treated as an InternalExtension. The
+// wrapped extension serializes exactly as ext does.
+func MarkInternal(ext Extension) Extension {
+	return internalExtension{Extension: ext}
+}
+
+type internalExtension struct {
+	Extension
+}
+
+func (internalExtension) InternalExtension() {}
+
+func (e internalExtension) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Extension)
+}
+
+// Redact returns r as profile p would send it, and whether anything was
+// withheld. Problems listed in an ErrorsExtension are redacted too. r itself
+// is not modified.
+func (r *Response) Redact(p RedactionProfile) (_ *Response, redacted bool) {
+	if p == DebugProfile {
+		return r, false
+	}
+	out := *r
+	out.Extensions = nil
+	for _, ext := range r.Extensions {
+		if _, internal := ext.(InternalExtension); internal {
+			redacted = true
+			continue
+		}
+		if errs, ok := ext.(ErrorsExtension); ok {
+			var changed bool
+			ext, changed = errs.redact(p)
+			redacted = redacted || changed
+		}
+		out.Extensions = append(out.Extensions, ext)
+	}
+	if p != ProductionProfile {
+		goto end
+	}
+	if def, ok := LookupErrorType(r.Type); ok && def.SensitiveDetail && out.Detail != "" {
+		out.Detail = WithheldDetail
+		redacted = true
+	}
+	if out.Status >= 500 && out.Status <= 599 {
+		if title := http.StatusText(out.Status); title != "" && out.Title != title {
+			out.Title = title
+			redacted = true
+		}
+		if out.Detail != "" {
+			out.Detail = ""
+			redacted = true
+		}
+	}
+end:
+	if !redacted {
+		return r, false
+	}
+	return &out, true
+}
@@ -1,12 +1,17 @@
 package rfc9457
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/json/jsontext"
 	jsonv2 "encoding/json/v2"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"reflect"
+	"slices"
+	"strings"
+	"time"
 )
 
 var _ ResponsePayload = (*Response)(nil)
@@ -21,12 +26,40 @@ type Response struct {
 	Detail     string       `json:"detail,omitempty"`
 	Instance   string       `json:"instance,omitempty"`
 	Extensions []Extension  `json:"extensions,omitempty"`
+
+	// OccurrenceID is the unique ID embedded in Instance when the instance was
+	// produced by an InstanceGenerator. It is not serialized but is included
+	// in log entries so reported problems can be looked up.
+	OccurrenceID string `json:"-"`
+
+	request      *http.Request
+	httpResponse *http.Response
+	internal     map[string]any
+	messageID    string
+	messageArgs  map[string]any
+	stack        []StackFrame
+	cause        error
+	decodeErrs   []error
 }
 
 func (r *Response) AddExtension(ext Extension) {
 	r.Extensions = append(r.Extensions, ext)
 }
 
+// AddInternalContext attaches a value that is recorded with the occurrence
+// (see OccurrenceStore) but never serialized to clients.
+func (r *Response) AddInternalContext(key string, value any) {
+	if r.internal == nil {
+		r.internal = make(map[string]any)
+	}
+	r.internal[key] = value
+}
+
+// InternalContext returns the values added with AddInternalContext.
+func (r *Response) InternalContext() map[string]any {
+	return r.internal
+}
+
 func (r *Response) Error() string {
 	return SprintfMany(r.Title, "\n",
 		"title=%s", r.Title,
@@ -48,14 +81,54 @@ func (r *Response) HTTPStatusCode() int {
 func (*Response) ResponsePayload() {}
 
 func NewResponse(args ResponseArgs) *Response {
-	return &Response{
+	r := &Response{
 		Type:       args.Type,
 		Title:      args.Title,
 		Status:     args.Status,
 		Detail:     args.Detail,
 		Instance:   args.Instance,
 		Extensions: args.Extensions,
+		request:    args.Request,
+
+		messageID:   args.MessageID,
+		messageArgs: args.MessageArgs,
+		cause:       args.Cause,
 	}
+	if c := GetCallerCapture(); c != nil {
+		r.stack = c.Capture(1)
+	}
+	defer notifyObservers(func(o Observer) { o.ProblemConstructed(r) })
+	if args.Request == nil {
+		goto end
+	}
+	r.AddInternalContext("method", args.Request.Method)
+	r.AddInternalContext("url", args.Request.URL.String())
+	r.AddInternalContext("remote_addr", args.Request.RemoteAddr)
+	if r.Instance == "" {
+		g := GetInstanceGenerator()
+		if g != nil {
+			r.SetOccurrence(g.NewOccurrence(args.Request))
+		}
+	}
+end:
+	return r
+}
+
+// SetCause records the error that led to r. It is never serialized but is
+// returned by Unwrap and shown by %+v.
+func (r *Response) SetCause(err error) {
+	r.cause = err
+}
+
+// Unwrap returns the cause set with SetCause or ResponseArgs.Cause.
+func (r *Response) Unwrap() error {
+	return r.cause
+}
+
+// SetOccurrence sets Instance and OccurrenceID from an Occurrence.
+func (r *Response) SetOccurrence(o Occurrence) {
+	r.Instance = o.Instance
+	r.OccurrenceID = o.ID
 }
 
 type ResponseArgs struct {
@@ -65,16 +138,181 @@ type ResponseArgs struct {
 	Detail     string       `json:"detail"`
 	Instance   string       `json:"instance"`
 	Extensions []Extension  `json:"extensions"`
+
+	// Request, when set and Instance is empty, is passed to the generator
+	// installed by SetInstanceGenerator to assign a unique Instance.
+	Request *http.Request `json:"-"`
+
+	// MessageID selects the catalog message used as the localized Detail;
+	// MessageArgs fills its {name} placeholders. See SetMessageCatalog.
+	MessageID   string         `json:"-"`
+	MessageArgs map[string]any `json:"-"`
+
+	// Cause is the error that led to the problem; see Response.SetCause.
+	Cause error `json:"-"`
 }
 
 func (r *ResponseArgs) AddExtension(ext Extension) {
 	r.Extensions = append(r.Extensions, ext)
 }
 
-func (r *Response) Write(w http.ResponseWriter) error {
+// Localize returns a copy of r with Title and Detail translated into lang
+// from catalog, along with the languages actually used. Title is looked up
+// under TitleMessageID and Detail under the MessageID given in ResponseArgs;
+// either is left unchanged when the catalog has no translation, in which case
+// the catalog's default language is assumed.
+func (r *Response) Localize(catalog *MessageCatalog, lang string) (_ *Response, langs []string) {
+	out := *r
+	use := func(l string) {
+		if !slices.Contains(langs, l) {
+			langs = append(langs, l)
+		}
+	}
+	msg, used, ok := catalog.Render(lang, MessageKey{Type: r.Type, MessageID: TitleMessageID}, r.messageArgs)
+	if ok {
+		out.Title = msg
+	} else {
+		used = catalog.DefaultLanguage()
+	}
+	use(used)
+	if r.messageID != "" {
+		msg, used, ok = catalog.Render(lang, MessageKey{Type: r.Type, MessageID: r.messageID}, r.messageArgs)
+		if ok {
+			out.Detail = msg
+			use(used)
+		}
+	}
+	if out.Detail == r.Detail && r.Detail != "" {
+		use(catalog.DefaultLanguage())
+	}
+	return &out, langs
+}
+
+// Write sends r as an application/problem+json response. It returns a
+// *WriteError without writing if w is a CommitTracker that has already
+// committed, or if r.Status is not 4xx/5xx and the StatusPolicy is
+// RejectInvalidStatus; otherwise invalid statuses are sent as 500. The
+// RedactionProfile is applied to what is sent, which includes any captured
+// stack as a DebugExtension only under DebugProfile, and then the Scrubber,
+// if set, to what is sent and logged; r itself is unchanged. Observers are
+// notified and the occurrence recorded only if the problem was encoded
+// without error.
+func (r *Response) Write(w http.ResponseWriter) (err error) {
+	var status int
+	var logged *Response
+	start := time.Now()
+	out := r
+	catalog := GetMessageCatalog()
+
+	status, err = r.checkWritable(w)
+	if err != nil {
+		goto end
+	}
+	if catalog != nil && r.request != nil {
+		var langs []string
+		out, langs = r.Localize(catalog, catalog.Negotiate(r.request.Header.Get("Accept-Language")))
+		for i, lang := range langs {
+			langs[i] = formatLanguageTag(lang)
+		}
+		w.Header().Set("Content-Language", strings.Join(langs, ", "))
+		w.Header().Add("Vary", "Accept-Language")
+	}
+	out, logged = r.prepareWrite(out, status)
 	w.Header().Set("Content-Type", "application/problem+json") // RFC 9457 media type
-	w.WriteHeader(r.Status)
-	return json.NewEncoder(w).Encode(r)
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(out)
+	if err != nil {
+		goto end
+	}
+	r.written(logged, status, time.Since(start))
+end:
+	return err
+}
+
+// prepareWrite returns out, a possibly localized r, as it is sent with
+// status, and r as it is logged and recorded. Any captured stack is added
+// and the RedactionProfile applied, logging the full original if anything
+// is withheld; the Scrubber, if set, is then applied to both.
+func (r *Response) prepareWrite(out *Response, status int) (sent, logged *Response) {
+	logged = r
+	if status != out.Status {
+		normalized := *out
+		normalized.Status = status
+		out = &normalized
+	}
+	out = out.withDebugExtension()
+	if scrubber := GetScrubber(); scrubber != nil {
+		logged, _ = scrubber.scrub(r, false)
+	}
+	if redacted, ok := out.Redact(GetRedactionProfile()); ok {
+		r.logger(WriteComponent).Info("Redacted problem details", logged.withStackAttr(
+			"profile", GetRedactionProfile(),
+			"occurrence_id", logged.OccurrenceID,
+			"type", logged.Type,
+			"title", logged.Title,
+			"status", logged.Status,
+			"detail", logged.Detail,
+			"instance", logged.Instance,
+			"extensions", logged.Extensions,
+		)...)
+		out = redacted
+	}
+	if scrubber := GetScrubber(); scrubber != nil {
+		out, _ = scrubber.Scrub(out)
+	}
+	return out, logged
+}
+
+// written notifies observers that r was sent with status, and records and
+// logs its occurrence, if any, using logged from prepareWrite.
+func (r *Response) written(logged *Response, status int, elapsed time.Duration) {
+	r.notifyWritten(status, elapsed)
+	if r.OccurrenceID == "" {
+		return
+	}
+	logged.recordOccurrence()
+	r.logger(WriteComponent).Info("Problem occurrence", logged.withStackAttr(
+		"occurrence_id", logged.OccurrenceID,
+		"instance", logged.Instance,
+		"type", logged.Type,
+		"status", logged.Status,
+		"detail", logged.Detail,
+	)...)
+}
+
+// logger returns the logger for c, preferring one carried by the request
+// context.
+func (r *Response) logger(c LogComponent) *slog.Logger {
+	if r.request != nil {
+		return LoggerFrom(r.request.Context(), c)
+	}
+	return ComponentLogger(c)
+}
+
+func (r *Response) notifyWritten(status int, elapsed time.Duration) {
+	ctx := context.Background()
+	if r.request != nil {
+		ctx = r.request.Context()
+	}
+	notifyObservers(func(o Observer) { o.ProblemWritten(ctx, r, status, elapsed) })
+}
+
+func (r *Response) recordOccurrence() {
+	store := GetOccurrenceStore()
+	if store == nil {
+		return
+	}
+	ctx := context.Background()
+	if r.request != nil {
+		ctx = context.WithoutCancel(r.request.Context())
+	}
+	err := store.SaveOccurrence(ctx, NewOccurrenceRecord(r))
+	if err != nil {
+		r.logger(OccurrenceComponent).Error("Failed to record problem occurrence",
+			"occurrence_id", r.OccurrenceID,
+			"error", err,
+		)
+	}
 }
 
 func (r *Response) UnmarshalJSON(data []byte) error {
@@ -102,10 +340,12 @@ func (r *Response) UnmarshalJSON(data []byte) error {
 
 	// Unmarshal extensions into their concrete types
 	r.Extensions = make([]Extension, 0, len(temp.Extensions))
+	r.decodeErrs = nil
 	for i, raw := range temp.Extensions {
 		ext, err := unmarshalExtension(raw, i)
 		if err != nil {
-			Logger().Error("Failed to unmarshal extension",
+			r.decodeErrs = append(r.decodeErrs, err)
+			logDecodeFailure(slog.LevelWarn, "Failed to unmarshal extension", raw,
 				"index", i,
 				"error", err,
 			)
@@ -145,10 +385,21 @@ func unmarshalExtension(raw jsontext.Value, index int) (Extension, error) {
 		return nil, fmt.Errorf("failed to unmarshal extension at index %d: %w", index, err)
 	}
 
-	Logger().Error("Extension did not match any registered type, using map[string]any",
+	logDecodeFailure(slog.LevelDebug, "Extension did not match any registered type, using map[string]any", raw,
 		"index", index,
-		"data", fallback,
 	)
 
 	return fallback, nil
 }
+
+// logDecodeFailure logs an extension decoding issue as the
+// DecodeFailurePolicy allows.
+func logDecodeFailure(level slog.Level, msg string, raw jsontext.Value, args ...any) {
+	switch GetDecodeFailurePolicy() {
+	case SilenceDecodeFailures:
+		return
+	case LogDecodeFailuresWithData:
+		args = append(args, "data", string(raw))
+	}
+	ComponentLogger(DecodeComponent).Log(context.Background(), level, msg, args...)
+}
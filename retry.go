@@ -0,0 +1,293 @@
+package rfc9457
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock abstracts time so retry behavior can be tested without sleeping.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d or until ctx is done, returning ctx.Err() in the
+	// latter case.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// SystemClock is the Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Retry policy defaults.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 100 * time.Millisecond
+	DefaultMaxDelay    = 30 * time.Second
+)
+
+// RetryPolicy decides whether and when a failed request is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first;
+	// DefaultMaxAttempts when zero.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry, doubling after each;
+	// DefaultBaseDelay when zero.
+	BaseDelay time.Duration
+	// MaxDelay caps backoff. A server-requested delay longer than this stops
+	// retrying rather than being shortened. DefaultMaxDelay when zero.
+	MaxDelay time.Duration
+	// Clock defaults to SystemClock.
+	Clock Clock
+	// Rand returns a value in [0, 1) used for jitter; defaults to
+	// math/rand/v2.Float64.
+	Rand func() float64
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return DefaultMaxDelay
+	}
+	return p.MaxDelay
+}
+
+func (p RetryPolicy) clock() Clock {
+	if p.Clock == nil {
+		return SystemClock
+	}
+	return p.Clock
+}
+
+// IsRetryable classifies problem. An explicit "retryable" extension member
+// wins, then the Retry flag of the registered type, then the status: 408,
+// 425, 429 and 5xx other than 501, 505 and 511 are retryable.
+func IsRetryable(problem *Response) bool {
+	if value, ok := problem.ExtensionMember("retryable"); ok {
+		if retryable, isBool := value.(bool); isBool {
+			return retryable
+		}
+	}
+	if def, ok := LookupErrorType(problem.Type); ok {
+		switch def.Retry {
+		case AlwaysRetry:
+			return true
+		case NeverRetry:
+			return false
+		}
+	}
+	switch problem.Status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported, http.StatusNetworkAuthenticationRequired:
+		return false
+	}
+	return problem.Status >= 500 && problem.Status <= 599
+}
+
+// Delay returns how long to wait before retry number attempt (1 for the
+// first retry) of problem. A Retry-After header on the problem's
+// HTTPResponse, in seconds or HTTP-date form, takes precedence, then an
+// exhausted rate_limit extension's reset; otherwise the delay is
+// exponential backoff with equal jitter. ok is false if the server asked
+// for a delay longer than MaxDelay.
+func (p RetryPolicy) Delay(attempt int, problem *Response) (delay time.Duration, ok bool) {
+	delay, found := p.serverDelay(problem)
+	if found {
+		ok = delay <= p.maxDelay()
+		goto end
+	}
+	delay = p.backoff(attempt)
+	ok = true
+end:
+	return delay, ok
+}
+
+func (p RetryPolicy) serverDelay(problem *Response) (delay time.Duration, found bool) {
+	if resp := problem.HTTPResponse(); resp != nil {
+		delay, found = ParseRetryAfter(resp.Header.Get("Retry-After"), p.clock().Now())
+		if found {
+			goto end
+		}
+	}
+	if value, ok := problem.ExtensionMember("rate_limit"); ok {
+		limit, isMap := value.(map[string]any)
+		if !isMap {
+			goto end
+		}
+		remaining, _ := limit["remaining"].(float64)
+		reset, hasReset := limit["reset"].(float64)
+		if hasReset && remaining <= 0 && reset >= 0 {
+			delay = time.Duration(reset * float64(time.Second))
+			found = true
+		}
+	}
+end:
+	return delay, found
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	delay := p.maxDelay()
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt <= 32 {
+		if d := base << (attempt - 1); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	random := p.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+	half := delay / 2
+	return half + time.Duration(random()*float64(delay-half))
+}
+
+// ParseRetryAfter parses a Retry-After header value, which is either a
+// number of seconds or an HTTP-date, returning the delay relative to now.
+func ParseRetryAfter(value string, now time.Time) (delay time.Duration, ok bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		goto end
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds >= 0 {
+			delay, ok = time.Duration(seconds)*time.Second, true
+		}
+		goto end
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		delay, ok = max(at.Sub(now), 0), true
+	}
+end:
+	return delay, ok
+}
+
+// RetryClient performs requests with retries for retryable problems. It
+// works with clients whose transport is a Transport, in which case problems
+// arrive as errors, and with plain clients, whose error replies are parsed
+// with ParseHTTPResponse.
+//
+// Requests with a body are only retried if req.GetBody is set, as it is for
+// requests made by http.NewRequest with common body types.
+type RetryClient struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	Policy RetryPolicy
+}
+
+func (c *RetryClient) client() *http.Client {
+	if c.Client == nil {
+		return http.DefaultClient
+	}
+	return c.Client
+}
+
+// Do sends req, retrying while the reply is a retryable problem and the
+// policy allows.
+func (c *RetryClient) Do(req *http.Request) (resp *http.Response, err error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		var problem *Response
+		var attemptReq *http.Request
+		var delay time.Duration
+		var ok bool
+
+		attemptReq, err = c.requestForAttempt(req, attempt)
+		if err != nil {
+			goto end
+		}
+		resp, err = c.client().Do(attemptReq)
+		problem = c.problemFrom(resp, err)
+		if problem == nil || !IsRetryable(problem) || attempt >= c.Policy.maxAttempts() {
+			goto end
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			goto end
+		}
+		delay, ok = c.Policy.Delay(attempt, problem)
+		if !ok {
+			goto end
+		}
+		LoggerFrom(ctx, ClientComponent).Debug("Retrying problem response",
+			"url", req.URL.String(),
+			"type", problem.Type,
+			"status", problem.Status,
+			"attempt", attempt,
+			"delay", delay,
+		)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		err = c.Policy.clock().Sleep(ctx, delay)
+		if err != nil {
+			resp = nil
+			goto end
+		}
+	}
+end:
+	return resp, err
+}
+
+func (c *RetryClient) requestForAttempt(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// problemFrom extracts the problem from a Transport error or parses it from
+// an error reply.
+func (c *RetryClient) problemFrom(resp *http.Response, err error) (problem *Response) {
+	if err != nil {
+		if !errors.As(err, &problem) {
+			problem = nil
+		}
+		goto end
+	}
+	if resp.StatusCode < 400 {
+		goto end
+	}
+	problem, err = ParseHTTPResponse(resp)
+	if err != nil {
+		problem = nil
+	}
+end:
+	return problem
+}
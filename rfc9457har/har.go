@@ -0,0 +1,333 @@
+// Package rfc9457har checks the error responses recorded in a HAR (HTTP
+// Archive) file for problem details compliance, and summarizes the problem
+// types seen per endpoint. It is what the rfc9457 har command runs.
+package rfc9457har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// FindingKind classifies a Finding.
+type FindingKind string
+
+const (
+	NonCompliant   FindingKind = "non-compliant"
+	UnknownType    FindingKind = "unknown-type"
+	StatusMismatch FindingKind = "status-mismatch"
+	LeakedDetail   FindingKind = "leaked-detail"
+)
+
+// Finding is one problem with a recorded error response.
+type Finding struct {
+	// Entry is the index of the entry in the HAR log.
+	Entry   int
+	Method  string
+	URL     string
+	Status  int
+	Kind    FindingKind
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("#%d %s %s -> %d: %s: %s", f.Entry, f.Method, f.URL, f.Status, f.Kind, f.Message)
+}
+
+// LeakPattern recognizes internal details that should not reach clients.
+type LeakPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DefaultLeakPatterns find stack traces, SQL errors, connection strings and
+// server file paths.
+var DefaultLeakPatterns = []LeakPattern{
+	{Name: "Go stack trace", Pattern: regexp.MustCompile(`goroutine \d+ \[|\.go:\d+|panic: `)},
+	{Name: "stack trace", Pattern: regexp.MustCompile(`Traceback \(most recent call last\)|\n\s+at [\w$.]+\(|Exception in thread`)},
+	{Name: "SQL error", Pattern: regexp.MustCompile(`(?i)SQLSTATE|syntax error at or near|\bpq: |ORA-\d{5}|sqlite3?:`)},
+	{Name: "connection string", Pattern: regexp.MustCompile(`(?i)\b(postgres(ql)?|mysql|mongodb(\+srv)?|redis|amqp)://[^\s"]+`)},
+	{Name: "server file path", Pattern: regexp.MustCompile(`(^|[\s"'(])(/home/|/usr/|/var/|/opt/|/srv/|[A-Z]:\\)`)},
+}
+
+// Analyzer checks HAR files. The zero value uses the package registry and
+// DefaultLeakPatterns.
+type Analyzer struct {
+	// KnownType reports whether a problem type is acceptable. By default
+	// about:blank and types registered with rfc9457.RegisterErrorType are.
+	KnownType func(rfc9457.ErrorTypeURI) bool
+	// LeakPatterns defaults to DefaultLeakPatterns.
+	LeakPatterns []LeakPattern
+}
+
+// Report is the result of analyzing a HAR file.
+type Report struct {
+	// ErrorResponses counts the entries with a 4xx or 5xx status.
+	ErrorResponses int
+	Findings       []Finding
+	Endpoints      []EndpointSummary
+}
+
+// EndpointSummary lists the problem types an endpoint returned. Path has
+// ID-like segments replaced by {id}.
+type EndpointSummary struct {
+	Method string
+	Path   string
+	Types  []TypeCount
+}
+
+// TypeCount counts the responses with one type and status.
+type TypeCount struct {
+	Type   rfc9457.ErrorTypeURI
+	Status int
+	Count  int
+}
+
+// har is the part of the HAR 1.2 format the analyzer reads.
+type har struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Headers []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// contentType prefers the Content-Type header over content.mimeType, which
+// browsers sometimes rewrite.
+func (e *harEntry) contentType() string {
+	for _, h := range e.Response.Headers {
+		if strings.EqualFold(h.Name, "Content-Type") {
+			return h.Value
+		}
+	}
+	return e.Response.Content.MimeType
+}
+
+func (e *harEntry) body() ([]byte, error) {
+	if e.Response.Content.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(e.Response.Content.Text)
+	}
+	return []byte(e.Response.Content.Text), nil
+}
+
+// Analyze reads a HAR file from r and checks its error responses.
+func (a *Analyzer) Analyze(r io.Reader) (report *Report, err error) {
+	var doc har
+	var summary endpointCounts
+
+	err = json.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		err = fmt.Errorf("parsing HAR file: %w", err)
+		goto end
+	}
+	report = &Report{}
+	summary = make(endpointCounts)
+	for i := range doc.Log.Entries {
+		entry := &doc.Log.Entries[i]
+		if entry.Response.Status < 400 {
+			continue
+		}
+		report.ErrorResponses++
+		problem, findings := a.checkEntry(i, entry)
+		report.Findings = append(report.Findings, findings...)
+		if problem != nil {
+			summary.add(entry.Request.Method, entry.Request.URL, problem.Type, entry.Response.Status)
+		}
+	}
+	report.Endpoints = summary.sorted()
+end:
+	return report, err
+}
+
+func (a *Analyzer) checkEntry(index int, entry *harEntry) (problem *rfc9457.Response, findings []Finding) {
+	var body []byte
+	var violations []rfc9457.Violation
+	var mediaType string
+	var err error
+	status := entry.Response.Status
+
+	add := func(kind FindingKind, format string, args ...any) {
+		findings = append(findings, Finding{
+			Entry:   index,
+			Method:  entry.Request.Method,
+			URL:     entry.Request.URL,
+			Status:  status,
+			Kind:    kind,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	body, err = entry.body()
+	if err != nil {
+		add(NonCompliant, "undecodable base64 content: %v", err)
+		goto end
+	}
+	for _, leak := range a.leakPatterns() {
+		if leak.Pattern.Match(body) {
+			add(LeakedDetail, "body contains a %s", leak.Name)
+		}
+	}
+	mediaType, _, _ = mime.ParseMediaType(entry.contentType())
+	switch mediaType {
+	case string(rfc9457.ApplicationProblemJSON):
+		problem, violations, err = rfc9457.ValidateJSON(body)
+	case string(rfc9457.ApplicationProblemXML):
+		problem = &rfc9457.Response{}
+		err = rfc9457.UnmarshalProblemXML(body, problem)
+		if err == nil {
+			violations = rfc9457.Validate(problem)
+		}
+	default:
+		add(NonCompliant, "Content-Type is %q, want %s", mediaType, rfc9457.ApplicationProblemJSON)
+		goto end
+	}
+	if err != nil {
+		problem = nil
+		add(NonCompliant, "invalid problem document: %v", err)
+		goto end
+	}
+	for _, v := range violations {
+		kind := NonCompliant
+		if v.Member == "status" {
+			kind = StatusMismatch
+		}
+		add(kind, "%s", v)
+	}
+	if problem.Status != 0 && problem.Status != status {
+		add(StatusMismatch, "status member %d differs from HTTP status %d", problem.Status, status)
+	}
+	if def, ok := rfc9457.LookupErrorType(problem.Type); ok && def.Status != 0 && def.Status != status && problem.Status == 0 {
+		add(StatusMismatch, "HTTP status %d does not match status %d registered for %s", status, def.Status, problem.Type)
+	}
+	if !a.knownType(problem.Type) {
+		add(UnknownType, "type %s is not in the catalog", problem.Type)
+	}
+end:
+	return problem, findings
+}
+
+func (a *Analyzer) knownType(t rfc9457.ErrorTypeURI) bool {
+	if a.KnownType != nil {
+		return a.KnownType(t)
+	}
+	if t == "" || t == rfc9457.AboutBlankErrorType {
+		return true
+	}
+	_, ok := rfc9457.LookupErrorType(t)
+	return ok
+}
+
+func (a *Analyzer) leakPatterns() []LeakPattern {
+	if a.LeakPatterns == nil {
+		return DefaultLeakPatterns
+	}
+	return a.LeakPatterns
+}
+
+type endpointKey struct {
+	method, path string
+}
+
+type typeKey struct {
+	typ    rfc9457.ErrorTypeURI
+	status int
+}
+
+type endpointCounts map[endpointKey]map[typeKey]int
+
+func (c endpointCounts) add(method, rawURL string, typ rfc9457.ErrorTypeURI, status int) {
+	if typ == "" {
+		typ = rfc9457.AboutBlankErrorType
+	}
+	key := endpointKey{method: method, path: EndpointPath(rawURL)}
+	if c[key] == nil {
+		c[key] = make(map[typeKey]int)
+	}
+	c[key][typeKey{typ: typ, status: status}]++
+}
+
+func (c endpointCounts) sorted() []EndpointSummary {
+	summaries := make([]EndpointSummary, 0, len(c))
+	for key, types := range c {
+		summary := EndpointSummary{Method: key.method, Path: key.path}
+		for tk, count := range types {
+			summary.Types = append(summary.Types, TypeCount{Type: tk.typ, Status: tk.status, Count: count})
+		}
+		slices.SortFunc(summary.Types, func(a, b TypeCount) int {
+			if a.Status != b.Status {
+				return a.Status - b.Status
+			}
+			return strings.Compare(string(a.Type), string(b.Type))
+		})
+		summaries = append(summaries, summary)
+	}
+	slices.SortFunc(summaries, func(a, b EndpointSummary) int {
+		return strings.Compare(a.Path+" "+a.Method, b.Path+" "+b.Method)
+	})
+	return summaries
+}
+
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F-]{16,}|[0-7][0-9A-HJKMNP-TV-Z]{25})$`)
+
+// EndpointPath returns the path of rawURL with numeric, UUID, ULID and long
+// hexadecimal segments replaced by {id}, so requests for different resources
+// group under one endpoint.
+func EndpointPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// WriteText writes the findings followed by the per-endpoint summary.
+func (r *Report) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	for _, f := range r.Findings {
+		fmt.Fprintln(&sb, f)
+	}
+	if len(r.Findings) > 0 {
+		fmt.Fprintln(&sb)
+	}
+	fmt.Fprintln(&sb, "Problem types by endpoint:")
+	for _, e := range r.Endpoints {
+		fmt.Fprintf(&sb, "  %s %s\n", e.Method, e.Path)
+		for _, t := range e.Types {
+			fmt.Fprintf(&sb, "    %d %s x%d\n", t.Status, t.Type, t.Count)
+		}
+	}
+	fmt.Fprintf(&sb, "%d error responses, %d findings\n", r.ErrorResponses, len(r.Findings))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
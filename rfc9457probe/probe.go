@@ -0,0 +1,333 @@
+// Package rfc9457probe checks that a running API answers malformed requests
+// with valid RFC 9457 problem documents. It is meant for local servers, such
+// as those started with net/http/httptest, and is what the rfc9457 probe
+// command runs.
+package rfc9457probe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// DefaultOversizedBodySize is the size of the body sent by OversizedBody
+// cases.
+const DefaultOversizedBodySize = 4 << 20
+
+// CaseKind names a kind of malformed request.
+type CaseKind string
+
+const (
+	BadType       CaseKind = "bad-type"
+	MissingParam  CaseKind = "missing-param"
+	WrongMethod   CaseKind = "wrong-method"
+	OversizedBody CaseKind = "oversized-body"
+)
+
+// Case is one malformed request derived from a route.
+type Case struct {
+	Kind  CaseKind
+	Route Route
+	// Target is the parameter or method that was made invalid.
+	Target string
+	Method string
+	URL    string
+	Body   []byte
+}
+
+// Prober sends malformed requests to BaseURL.
+type Prober struct {
+	BaseURL string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// OversizedBodySize defaults to DefaultOversizedBodySize.
+	OversizedBodySize int
+	// KnownType reports whether a problem type is acceptable. By default
+	// about:blank and types registered with rfc9457.RegisterErrorType are.
+	KnownType func(rfc9457.ErrorTypeURI) bool
+}
+
+// Result is the outcome of one case.
+type Result struct {
+	Case   Case
+	Status int
+	// Accepted is set when the server answered a malformed request with a
+	// non-error status. It is reported but is not a failure.
+	Accepted   bool
+	Violations []string
+	Err        error
+}
+
+// Failed reports whether the reply was not a conforming problem document.
+func (r Result) Failed() bool {
+	return r.Err != nil || len(r.Violations) > 0
+}
+
+// Cases returns the malformed requests the prober sends for routes.
+func (p *Prober) Cases(routes []Route) []Case {
+	var cases []Case
+	methodsByPath := make(map[string][]string)
+	for _, route := range routes {
+		methodsByPath[route.Path] = append(methodsByPath[route.Path], route.Method)
+	}
+	for _, route := range routes {
+		for _, param := range route.Params {
+			if param.Type != "string" {
+				cases = append(cases, p.newCase(BadType, route, param.Name, route.Method, malformedValue(param.Type), nil))
+			}
+			if param.In == InQuery && param.Required {
+				cases = append(cases, p.newCase(MissingParam, route, param.Name, route.Method, "", nil))
+			}
+		}
+		if method := unusedMethod(methodsByPath[route.Path]); method != "" && route.Method == methodsByPath[route.Path][0] {
+			cases = append(cases, p.newCase(WrongMethod, route, method, method, "", nil))
+		}
+		if route.HasBody {
+			cases = append(cases, p.newCase(OversizedBody, route, "body", route.Method, "", oversizedBody(p.oversizedBodySize())))
+		}
+	}
+	return cases
+}
+
+// newCase builds a case for route, replacing the value of the parameter
+// named target with value, or omitting it for MissingParam.
+func (p *Prober) newCase(kind CaseKind, route Route, target, method, value string, body []byte) Case {
+	path := route.Path
+	query := url.Values{}
+	for _, param := range route.Params {
+		v := sampleValue(param.Type)
+		switch {
+		case kind == BadType && param.Name == target:
+			v = value
+		case kind == MissingParam && param.Name == target:
+			continue
+		case param.In == InQuery && !param.Required:
+			continue
+		}
+		if param.In == InPath {
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(v))
+			continue
+		}
+		query.Set(param.Name, v)
+	}
+	u := strings.TrimSuffix(p.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return Case{Kind: kind, Route: route, Target: target, Method: method, URL: u, Body: body}
+}
+
+func sampleValue(typ string) string {
+	switch typ {
+	case "integer":
+		return "1"
+	case "number":
+		return "1.5"
+	case "boolean":
+		return "true"
+	}
+	return "sample"
+}
+
+func malformedValue(typ string) string {
+	if typ == "boolean" {
+		return "maybe"
+	}
+	return "abc"
+}
+
+// unusedMethod returns a method the path does not serve.
+func unusedMethod(methods []string) string {
+	for _, m := range []string{http.MethodDelete, http.MethodPatch, http.MethodPut, http.MethodPost, http.MethodGet} {
+		if !slices.Contains(methods, m) {
+			return m
+		}
+	}
+	return ""
+}
+
+func oversizedBody(size int) []byte {
+	const prefix, suffix = `{"data":"`, `"}`
+	body := bytes.Repeat([]byte("x"), max(size, len(prefix)+len(suffix)))
+	copy(body, prefix)
+	copy(body[len(body)-len(suffix):], suffix)
+	return body
+}
+
+func (p *Prober) oversizedBodySize() int {
+	if p.OversizedBodySize <= 0 {
+		return DefaultOversizedBodySize
+	}
+	return p.OversizedBodySize
+}
+
+func (p *Prober) client() *http.Client {
+	if p.Client == nil {
+		return http.DefaultClient
+	}
+	return p.Client
+}
+
+func (p *Prober) knownType(t rfc9457.ErrorTypeURI) bool {
+	if p.KnownType != nil {
+		return p.KnownType(t)
+	}
+	if t == "" || t == rfc9457.AboutBlankErrorType {
+		return true
+	}
+	_, ok := rfc9457.LookupErrorType(t)
+	return ok
+}
+
+// Run sends every case for routes and returns the report. It stops early
+// only if ctx is done.
+func (p *Prober) Run(ctx context.Context, routes []Route) (report *Report, err error) {
+	report = &Report{}
+	for _, c := range p.Cases(routes) {
+		err = ctx.Err()
+		if err != nil {
+			goto end
+		}
+		report.Results = append(report.Results, p.probe(ctx, c))
+	}
+end:
+	return report, err
+}
+
+func (p *Prober) probe(ctx context.Context, c Case) (result Result) {
+	var req *http.Request
+	var resp *http.Response
+	var body []byte
+	var mediaType string
+
+	result.Case = c
+	req, result.Err = http.NewRequestWithContext(ctx, c.Method, c.URL, bytes.NewReader(c.Body))
+	if result.Err != nil {
+		goto end
+	}
+	if c.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", string(rfc9457.ApplicationProblemJSON)+", application/json;q=0.9")
+	resp, result.Err = p.client().Do(req)
+	if result.Err != nil {
+		goto end
+	}
+	defer func() { _ = resp.Body.Close() }()
+	result.Status = resp.StatusCode
+	if resp.StatusCode < 400 {
+		result.Accepted = true
+		goto end
+	}
+	body, result.Err = io.ReadAll(io.LimitReader(resp.Body, rfc9457.DefaultMaxProblemBodySize))
+	if result.Err != nil {
+		goto end
+	}
+	mediaType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	result.Violations = p.check(resp.StatusCode, mediaType, body)
+end:
+	return result
+}
+
+// check validates an error reply.
+func (p *Prober) check(status int, mediaType string, body []byte) (violations []string) {
+	var problem *rfc9457.Response
+	var found []rfc9457.Violation
+	var err error
+
+	switch mediaType {
+	case string(rfc9457.ApplicationProblemJSON):
+		problem, found, err = rfc9457.ValidateJSON(body)
+	case string(rfc9457.ApplicationProblemXML):
+		problem = &rfc9457.Response{}
+		err = rfc9457.UnmarshalProblemXML(body, problem)
+		if err == nil {
+			found = rfc9457.Validate(problem)
+		}
+	default:
+		violations = append(violations, fmt.Sprintf("Content-Type is %q, want %s", mediaType, rfc9457.ApplicationProblemJSON))
+		goto end
+	}
+	if err != nil {
+		violations = append(violations, fmt.Sprintf("invalid problem document: %v", err))
+		goto end
+	}
+	for _, v := range found {
+		violations = append(violations, v.String())
+	}
+	if problem.Status != 0 && problem.Status != status {
+		violations = append(violations, fmt.Sprintf("status member %d differs from HTTP status %d", problem.Status, status))
+	}
+	if !p.knownType(problem.Type) {
+		violations = append(violations, fmt.Sprintf("type %s is not in the catalog", problem.Type))
+	}
+end:
+	return violations
+}
+
+// Report is the outcome of a probe run.
+type Report struct {
+	Results []Result
+}
+
+// Failures returns the results that failed.
+func (r *Report) Failures() []Result {
+	var failures []Result
+	for _, result := range r.Results {
+		if result.Failed() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Passed reports whether every error reply was a conforming problem.
+func (r *Report) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// WriteText writes one line per case, with violations indented beneath
+// failures, followed by a summary.
+func (r *Report) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+	accepted := 0
+	for _, result := range r.Results {
+		c := result.Case
+		verdict := "PASS"
+		switch {
+		case result.Failed():
+			verdict = "FAIL"
+		case result.Accepted:
+			verdict = "WARN"
+			accepted++
+		}
+		fmt.Fprintf(&buf, "%s %s %s: %s %s -> %s\n", verdict, c.Kind, c.Target, c.Method, c.URL, statusText(result))
+		if result.Err != nil {
+			fmt.Fprintf(&buf, "    %v\n", result.Err)
+		}
+		for _, v := range result.Violations {
+			fmt.Fprintf(&buf, "    %s\n", v)
+		}
+	}
+	fmt.Fprintf(&buf, "%d cases, %d failed, %d accepted malformed requests\n", len(r.Results), len(r.Failures()), accepted)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func statusText(result Result) string {
+	if result.Status == 0 {
+		return "no response"
+	}
+	if result.Accepted {
+		return fmt.Sprintf("%d (request accepted)", result.Status)
+	}
+	return fmt.Sprint(result.Status)
+}
@@ -0,0 +1,217 @@
+package rfc9457probe
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Parameter locations.
+const (
+	InPath  = "path"
+	InQuery = "query"
+)
+
+// Param is a path or query parameter of a route. Type is an OpenAPI
+// primitive type: string, integer, number or boolean.
+type Param struct {
+	Name     string
+	In       string
+	Type     string
+	Required bool
+}
+
+// Route is an operation to probe. Path uses OpenAPI {name} templates.
+type Route struct {
+	Method  string
+	Path    string
+	Params  []Param
+	HasBody bool
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// ParseRoutes reads a route list, one route per line:
+//
+//	GET /users/{id:integer}
+//	GET /search?q=string!&page=integer
+//	POST /users
+//
+// Path parameters are {name} or {name:type}; query parameters follow "?" as
+// name=type, with a trailing "!" marking them required. Untyped parameters
+// are strings, and POST, PUT and PATCH routes are assumed to take a body.
+// Blank lines and lines starting with "#" are ignored.
+func ParseRoutes(r io.Reader) (routes []Route, err error) {
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		var route Route
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		route, err = parseRoute(line)
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", lineNo, err)
+			goto end
+		}
+		routes = append(routes, route)
+	}
+	err = scanner.Err()
+end:
+	return routes, err
+}
+
+func parseRoute(line string) (route Route, err error) {
+	var query string
+
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		err = fmt.Errorf("want METHOD PATH, got %q", line)
+		goto end
+	}
+	route.Method = strings.ToUpper(fields[0])
+	route.HasBody = slices.Contains([]string{http.MethodPost, http.MethodPut, http.MethodPatch}, route.Method)
+	route.Path, query, _ = strings.Cut(fields[1], "?")
+
+	for _, segment := range strings.Split(route.Path, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name, typ, _ := strings.Cut(segment[1:len(segment)-1], ":")
+		route.Params = append(route.Params, Param{Name: name, In: InPath, Type: defaultType(typ), Required: true})
+		route.Path = strings.Replace(route.Path, segment, "{"+name+"}", 1)
+	}
+	if query == "" {
+		goto end
+	}
+	for _, spec := range strings.Split(query, "&") {
+		name, typ, _ := strings.Cut(spec, "=")
+		required := strings.HasSuffix(typ, "!")
+		typ = strings.TrimSuffix(typ, "!")
+		if name == "" {
+			err = fmt.Errorf("empty query parameter name in %q", line)
+			goto end
+		}
+		route.Params = append(route.Params, Param{Name: name, In: InQuery, Type: defaultType(typ), Required: required})
+	}
+end:
+	return route, err
+}
+
+func defaultType(typ string) string {
+	if typ == "" {
+		return "string"
+	}
+	return typ
+}
+
+// openAPIDocument is the part of an OpenAPI 3 document the probe uses.
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Parameters map[string]openAPIParameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Parameters  []openAPIParameter `json:"parameters"`
+	RequestBody json.RawMessage    `json:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref      string `json:"$ref"`
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Schema   struct {
+		Type string `json:"type"`
+	} `json:"schema"`
+}
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// LoadOpenAPI reads the routes of an OpenAPI 3 document in JSON form.
+// Parameters may be references to components/parameters; path-level
+// parameters apply to every operation on the path.
+func LoadOpenAPI(data []byte) (routes []Route, err error) {
+	var doc openAPIDocument
+
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		err = fmt.Errorf("parsing OpenAPI document: %w", err)
+		goto end
+	}
+	for path, item := range doc.Paths {
+		var shared []openAPIParameter
+		if raw, ok := item["parameters"]; ok {
+			err = json.Unmarshal(raw, &shared)
+			if err != nil {
+				err = fmt.Errorf("parsing parameters of %s: %w", path, err)
+				goto end
+			}
+		}
+		for _, method := range openAPIMethods {
+			var op openAPIOperation
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			err = json.Unmarshal(raw, &op)
+			if err != nil {
+				err = fmt.Errorf("parsing %s %s: %w", strings.ToUpper(method), path, err)
+				goto end
+			}
+			route := Route{
+				Method:  strings.ToUpper(method),
+				Path:    path,
+				HasBody: len(op.RequestBody) > 0,
+			}
+			for _, p := range append(slices.Clone(shared), op.Parameters...) {
+				p, err = doc.resolve(p)
+				if err != nil {
+					goto end
+				}
+				if p.In != InPath && p.In != InQuery {
+					continue
+				}
+				route.Params = append(route.Params, Param{
+					Name:     p.Name,
+					In:       p.In,
+					Type:     defaultType(p.Schema.Type),
+					Required: p.Required || p.In == InPath,
+				})
+			}
+			routes = append(routes, route)
+		}
+	}
+	sortRoutes(routes)
+end:
+	return routes, err
+}
+
+func (doc *openAPIDocument) resolve(p openAPIParameter) (openAPIParameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+	if !ok {
+		return p, fmt.Errorf("unsupported parameter reference %q", p.Ref)
+	}
+	resolved, ok := doc.Components.Parameters[name]
+	if !ok {
+		return p, fmt.Errorf("unresolved parameter reference %q", p.Ref)
+	}
+	return resolved, nil
+}
+
+func sortRoutes(routes []Route) {
+	slices.SortFunc(routes, func(a, b Route) int {
+		return strings.Compare(a.Path+" "+a.Method, b.Path+" "+b.Method)
+	})
+}
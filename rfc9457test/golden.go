@@ -0,0 +1,92 @@
+package rfc9457test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// GoldenDir is where golden files are read from and written to, relative to
+// the package under test.
+var GoldenDir = "testdata"
+
+// update rewrites golden files instead of comparing with them. The flag
+// is namespaced so it cannot clash with a test package's own -update flag.
+var update = flag.Bool("rfc9457test.update", false, "rewrite rfc9457test golden files")
+
+// AssertGolden compares the canonical form of r (see rfc9457.Canonicalizer)
+// with GoldenDir/name.json, rewriting the file instead when run with
+// -rfc9457test.update.
+func AssertGolden(t testing.TB, name string, r *rfc9457.Response) {
+	t.Helper()
+	got, err := rfc9457.Canonicalize(r)
+	if err != nil {
+		t.Fatalf("canonicalizing problem: %v", err)
+		return
+	}
+	assertGoldenBytes(t, name, got)
+}
+
+// AssertGoldenRecorder compares the canonical form of the JSON body in rec
+// with GoldenDir/name.json, rewriting the file instead when run with
+// -rfc9457test.update.
+func AssertGoldenRecorder(t testing.TB, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	AssertContentType(t, rec)
+	got, err := rfc9457.CanonicalizeJSON(rec.Body.Bytes())
+	if err != nil {
+		t.Fatalf("%v\nbody: %s", err, excerpt(rec.Body.Bytes()))
+		return
+	}
+	assertGoldenBytes(t, name, got)
+}
+
+func assertGoldenBytes(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(GoldenDir, name+".json")
+	if *update {
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = os.WriteFile(path, got, 0o644)
+		}
+		if err != nil {
+			t.Fatalf("updating golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -rfc9457test.update to create it): %v", err)
+		return
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match (run with -rfc9457test.update to accept):\n%s", path, lineDiff(string(got), string(want)))
+	}
+}
+
+// lineDiff shows the lines of got and want from the first that differs.
+func lineDiff(got, want string) string {
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(want, "\n")
+	first := 0
+	for first < len(gotLines) && first < len(wantLines) && gotLines[first] == wantLines[first] {
+		first++
+	}
+	var sb strings.Builder
+	for i := first; i < max(len(gotLines), len(wantLines)); i++ {
+		if i < len(wantLines) {
+			fmt.Fprintf(&sb, "line %d want: %s\n", i+1, wantLines[i])
+		}
+		if i < len(gotLines) {
+			fmt.Fprintf(&sb, "line %d got:  %s\n", i+1, gotLines[i])
+		}
+	}
+	return sb.String()
+}
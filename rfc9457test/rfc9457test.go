@@ -0,0 +1,237 @@
+// Package rfc9457test provides assertions for testing handlers that write
+// RFC 9457 problem documents.
+package rfc9457test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// maxBodyExcerpt bounds how much of a body failure messages include.
+const maxBodyExcerpt = 512
+
+// DecodeProblem checks that rec holds an application/problem+json body and
+// decodes it, failing t with the offending body if it cannot.
+func DecodeProblem(t testing.TB, rec *httptest.ResponseRecorder) *rfc9457.Response {
+	t.Helper()
+	AssertContentType(t, rec)
+
+	body := rec.Body.Bytes()
+	if len(bytes.TrimSpace(body)) == 0 {
+		t.Fatalf("problem body is empty (status %d)", rec.Code)
+		return nil
+	}
+	var got rfc9457.Response
+	err := json.Unmarshal(body, &got)
+	if err != nil {
+		t.Fatalf("problem body is not valid JSON: %v\nbody: %s", err, excerpt(body))
+		return nil
+	}
+	return &got
+}
+
+// AssertContentType checks that rec has the application/problem+json media
+// type; parameters such as charset are allowed.
+func AssertContentType(t testing.TB, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	contentType := rec.Header().Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != string(rfc9457.ApplicationProblemJSON) {
+		t.Errorf("Content-Type: got %q, want %q", contentType, rfc9457.ApplicationProblemJSON)
+	}
+}
+
+// AssertProblem decodes the problem in rec and checks that it, and the
+// recorded status code, equal want. All differences are reported.
+func AssertProblem(t testing.TB, rec *httptest.ResponseRecorder, want *rfc9457.Response) *rfc9457.Response {
+	t.Helper()
+	got := DecodeProblem(t, rec)
+	if got == nil {
+		return nil
+	}
+	if rec.Code != want.Status {
+		t.Errorf("HTTP status code: got %d, want %d", rec.Code, want.Status)
+	}
+	AssertEqual(t, got, want)
+	return got
+}
+
+// AssertEqual reports each field in which got differs from want.
+func AssertEqual(t testing.TB, got, want *rfc9457.Response) {
+	t.Helper()
+	for _, d := range Diff(got, want) {
+		t.Errorf("%s", d)
+	}
+}
+
+// AssertMatches decodes the problem in rec and checks it against matchers.
+func AssertMatches(t testing.TB, rec *httptest.ResponseRecorder, matchers ...Matcher) *rfc9457.Response {
+	t.Helper()
+	got := DecodeProblem(t, rec)
+	if got == nil {
+		return nil
+	}
+	if rec.Code != got.Status {
+		t.Errorf("HTTP status code %d does not match problem status %d", rec.Code, got.Status)
+	}
+	AssertMatch(t, got, matchers...)
+	return got
+}
+
+// AssertMatch checks got against matchers, reporting every mismatch.
+func AssertMatch(t testing.TB, got *rfc9457.Response, matchers ...Matcher) {
+	t.Helper()
+	for _, m := range matchers {
+		err := m.Match(got)
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+}
+
+// FieldDiff is one difference found by Diff. Got and Want are JSON
+// encodings so that absent and empty values are distinguishable.
+type FieldDiff struct {
+	Field string
+	Got   string
+	Want  string
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: got %s, want %s", d.Field, d.Got, d.Want)
+}
+
+// Diff compares the serialized members of two responses. Extensions are
+// compared by index through their JSON encoding, so a decoded
+// map[string]any equals the struct it was encoded from.
+func Diff(got, want *rfc9457.Response) (diffs []FieldDiff) {
+	add := func(field string, g, w any) {
+		gs, ws := encode(g), encode(w)
+		if gs != ws {
+			diffs = append(diffs, FieldDiff{Field: field, Got: gs, Want: ws})
+		}
+	}
+	add("Type", got.Type, want.Type)
+	add("Title", got.Title, want.Title)
+	add("Status", got.Status, want.Status)
+	add("Detail", got.Detail, want.Detail)
+	add("Instance", got.Instance, want.Instance)
+	for i := range max(len(got.Extensions), len(want.Extensions)) {
+		var g, w any
+		if i < len(got.Extensions) {
+			g = got.Extensions[i]
+		}
+		if i < len(want.Extensions) {
+			w = want.Extensions[i]
+		}
+		add(fmt.Sprintf("Extensions[%d]", i), g, w)
+	}
+	return diffs
+}
+
+// encode returns the canonical JSON of v; round-tripping through any sorts
+// object keys and normalizes numbers.
+func encode(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<unencodable %T: %v>", v, err)
+	}
+	var generic any
+	if json.Unmarshal(data, &generic) == nil {
+		data, _ = json.Marshal(generic)
+	}
+	return string(data)
+}
+
+func excerpt(body []byte) string {
+	if len(body) > maxBodyExcerpt {
+		return string(body[:maxBodyExcerpt]) + "…"
+	}
+	return string(body)
+}
+
+// Matcher checks one aspect of a problem, returning a descriptive error on
+// mismatch.
+type Matcher interface {
+	Match(got *rfc9457.Response) error
+}
+
+// MatcherFunc adapts a function to the Matcher interface.
+type MatcherFunc func(got *rfc9457.Response) error
+
+func (f MatcherFunc) Match(got *rfc9457.Response) error {
+	return f(got)
+}
+
+// HasType matches a problem whose Type is typ.
+func HasType(typ rfc9457.ErrorTypeURI) Matcher {
+	return MatcherFunc(func(got *rfc9457.Response) error {
+		if got.Type != typ {
+			return fmt.Errorf("type: got %q, want %q", got.Type, typ)
+		}
+		return nil
+	})
+}
+
+// HasStatus matches a problem whose Status is status.
+func HasStatus(status int) Matcher {
+	return MatcherFunc(func(got *rfc9457.Response) error {
+		if got.Status != status {
+			return fmt.Errorf("status: got %d, want %d", got.Status, status)
+		}
+		return nil
+	})
+}
+
+// HasTitle matches a problem whose Title is title.
+func HasTitle(title string) Matcher {
+	return MatcherFunc(func(got *rfc9457.Response) error {
+		if got.Title != title {
+			return fmt.Errorf("title: got %q, want %q", got.Title, title)
+		}
+		return nil
+	})
+}
+
+// DetailContains matches a problem whose Detail contains substr.
+func DetailContains(substr string) Matcher {
+	return MatcherFunc(func(got *rfc9457.Response) error {
+		if !strings.Contains(got.Detail, substr) {
+			return fmt.Errorf("detail: %q does not contain %q", got.Detail, substr)
+		}
+		return nil
+	})
+}
+
+// HasExtensionMember matches a problem with an extension that has the named
+// member, whatever its value.
+func HasExtensionMember(name string) Matcher {
+	return MatcherFunc(func(got *rfc9457.Response) error {
+		if _, ok := got.ExtensionMember(name); !ok {
+			return fmt.Errorf("extensions: no member %q in %s", name, encode(got.Extensions))
+		}
+		return nil
+	})
+}
+
+// HasExtensionValue matches a problem with an extension whose named member
+// has the same JSON encoding as value.
+func HasExtensionValue(name string, value any) Matcher {
+	return MatcherFunc(func(got *rfc9457.Response) error {
+		member, ok := got.ExtensionMember(name)
+		if !ok {
+			return fmt.Errorf("extensions: no member %q in %s", name, encode(got.Extensions))
+		}
+		if g, w := encode(member), encode(value); g != w {
+			return fmt.Errorf("extensions[%s]: got %s, want %s", name, g, w)
+		}
+		return nil
+	})
+}
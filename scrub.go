@@ -0,0 +1,291 @@
+package rfc9457
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ScrubRule replaces one kind of sensitive content in strings.
+type ScrubRule struct {
+	// Name identifies the rule in ScrubCounts.
+	Name    string
+	Pattern *regexp.Regexp
+	// Replacement is substituted for each match.
+	Replacement string
+	// Valid, when set, confirms a match before it is replaced, e.g. with a
+	// checksum.
+	Valid func(match string) bool
+}
+
+// EmailRule replaces email addresses.
+func EmailRule() ScrubRule {
+	return ScrubRule{
+		Name:        "email",
+		Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		Replacement: "[email]",
+	}
+}
+
+// CardNumberRule replaces payment card numbers of 13 to 19 digits,
+// optionally grouped by spaces or dashes, that pass the Luhn check.
+func CardNumberRule() ScrubRule {
+	return ScrubRule{
+		Name:        "card_number",
+		Pattern:     regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		Replacement: "[card]",
+		Valid:       luhnValid,
+	}
+}
+
+// BearerTokenRule replaces the credentials of bearer authorization values.
+func BearerTokenRule() ScrubRule {
+	return ScrubRule{
+		Name:        "bearer_token",
+		Pattern:     regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+		Replacement: "Bearer [token]",
+	}
+}
+
+// IPAddressRule replaces IPv4 and IPv6 addresses.
+func IPAddressRule() ScrubRule {
+	return ScrubRule{
+		Name:        "ip_address",
+		Pattern:     regexp.MustCompile(`(?i)\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`),
+		Replacement: "[ip]",
+		Valid: func(match string) bool {
+			return net.ParseIP(match) != nil
+		},
+	}
+}
+
+// RegexRule replaces matches of a custom pattern.
+func RegexRule(name, pattern, replacement string) (ScrubRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ScrubRule{}, err
+	}
+	return ScrubRule{Name: name, Pattern: re, Replacement: replacement}, nil
+}
+
+// DefaultScrubRules returns the bearer token, email, card number and IP
+// address rules.
+func DefaultScrubRules() []ScrubRule {
+	return []ScrubRule{BearerTokenRule(), EmailRule(), CardNumberRule(), IPAddressRule()}
+}
+
+func luhnValid(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// ScrubCount is the number of replacements a rule made in problems of one
+// type, which points at the code that leaks.
+type ScrubCount struct {
+	Rule  string
+	Type  ErrorTypeURI
+	Count int64
+}
+
+type scrubKey struct {
+	rule string
+	typ  ErrorTypeURI
+}
+
+// Scrubber applies ScrubRules to the Title, Detail and, recursively, the
+// extension values of problems, counting each replacement.
+type Scrubber struct {
+	rules []ScrubRule
+
+	mu     sync.Mutex
+	counts map[scrubKey]int64
+}
+
+// NewScrubber returns a Scrubber applying rules in order, or
+// DefaultScrubRules if none are given.
+func NewScrubber(rules ...ScrubRule) *Scrubber {
+	if len(rules) == 0 {
+		rules = DefaultScrubRules()
+	}
+	return &Scrubber{rules: rules, counts: make(map[scrubKey]int64)}
+}
+
+var scrubber struct {
+	sync.RWMutex
+	s *Scrubber
+}
+
+// SetScrubber installs the Scrubber that Response.Write applies to what it
+// sends and logs; nil disables scrubbing.
+func SetScrubber(s *Scrubber) {
+	scrubber.Lock()
+	defer scrubber.Unlock()
+	scrubber.s = s
+}
+
+func GetScrubber() *Scrubber {
+	scrubber.RLock()
+	defer scrubber.RUnlock()
+	return scrubber.s
+}
+
+// ScrubString applies the rules to s without counting.
+func (s *Scrubber) ScrubString(v string) string {
+	out, _ := s.scrubString(v, nil)
+	return out
+}
+
+// Scrub returns a copy of r with sensitive content replaced, and whether
+// anything was. Replacements are counted under r.Type.
+func (s *Scrubber) Scrub(r *Response) (*Response, bool) {
+	return s.scrub(r, true)
+}
+
+// ScrubCounts returns the replacements made so far, sorted by rule then type.
+func (s *Scrubber) ScrubCounts() []ScrubCount {
+	s.mu.Lock()
+	counts := make([]ScrubCount, 0, len(s.counts))
+	for key, n := range s.counts {
+		counts = append(counts, ScrubCount{Rule: key.rule, Type: key.typ, Count: n})
+	}
+	s.mu.Unlock()
+	slices.SortFunc(counts, func(a, b ScrubCount) int {
+		if c := strings.Compare(a.Rule, b.Rule); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Type), string(b.Type))
+	})
+	return counts
+}
+
+func (s *Scrubber) scrub(r *Response, count bool) (_ *Response, scrubbed bool) {
+	var hits map[string]int64
+	if count {
+		hits = make(map[string]int64)
+	}
+	out := *r
+	out.Title, scrubbed = s.scrubString(r.Title, hits)
+	detail, changed := s.scrubString(r.Detail, hits)
+	out.Detail, scrubbed = detail, scrubbed || changed
+	out.Extensions = slices.Clone(r.Extensions)
+	for i, ext := range r.Extensions {
+		out.Extensions[i], changed = s.scrubExtension(ext, hits)
+		scrubbed = scrubbed || changed
+	}
+	if count && len(hits) > 0 {
+		s.mu.Lock()
+		for rule, n := range hits {
+			s.counts[scrubKey{rule: rule, typ: r.Type}] += n
+		}
+		s.mu.Unlock()
+	}
+	if !scrubbed {
+		return r, false
+	}
+	return &out, true
+}
+
+func (s *Scrubber) scrubString(v string, hits map[string]int64) (string, bool) {
+	changed := false
+	for _, rule := range s.rules {
+		v = rule.Pattern.ReplaceAllStringFunc(v, func(match string) string {
+			if rule.Valid != nil && !rule.Valid(match) {
+				return match
+			}
+			changed = true
+			if hits != nil {
+				hits[rule.Name]++
+			}
+			return rule.Replacement
+		})
+	}
+	return v, changed
+}
+
+// scrubExtension scrubs ext through its JSON form. An extension that needed
+// scrubbing is replaced by its generic form, still marked internal if it
+// was.
+func (s *Scrubber) scrubExtension(ext Extension, hits map[string]int64) (Extension, bool) {
+	if errs, ok := ext.(ErrorsExtension); ok {
+		return s.scrubErrors(errs, hits != nil)
+	}
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return ext, false
+	}
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if dec.Decode(&value) != nil {
+		return ext, false
+	}
+	value, changed := s.scrubValue(value, hits)
+	if !changed {
+		return ext, false
+	}
+	if _, internal := ext.(InternalExtension); internal {
+		return MarkInternal(value), true
+	}
+	return value, true
+}
+
+// scrubErrors scrubs the problems of an aggregate, keeping them an
+// ErrorsExtension so that they are still redacted as problems. Replacements
+// are counted under each problem's own type.
+func (s *Scrubber) scrubErrors(e ErrorsExtension, count bool) (Extension, bool) {
+	changed := false
+	out := ErrorsExtension{Errors: make([]*Response, len(e.Errors))}
+	for i, p := range e.Errors {
+		out.Errors[i] = p
+		if p == nil {
+			continue
+		}
+		var c bool
+		out.Errors[i], c = s.scrub(p, count)
+		changed = changed || c
+	}
+	if !changed {
+		return e, false
+	}
+	return out, true
+}
+
+func (s *Scrubber) scrubValue(v any, hits map[string]int64) (any, bool) {
+	changed := false
+	switch t := v.(type) {
+	case string:
+		return s.scrubString(t, hits)
+	case map[string]any:
+		for key, value := range t {
+			var c bool
+			t[key], c = s.scrubValue(value, hits)
+			changed = changed || c
+		}
+	case []any:
+		for i, value := range t {
+			var c bool
+			t[i], c = s.scrubValue(value, hits)
+			changed = changed || c
+		}
+	}
+	return v, changed
+}
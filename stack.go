@@ -0,0 +1,145 @@
+package rfc9457
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// DefaultStackDepth is the number of frames captured when
+// CallerCaptureArgs.Depth is zero.
+const DefaultStackDepth = 32
+
+// packagePrefix matches functions of this package but not of its
+// subpackages.
+const packagePrefix = "github.com/mikeschinkel/go-rfc9457."
+
+// StackFrame is one captured caller.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f StackFrame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+// DebugExtension carries the stack captured when the problem was
+// constructed. Response.Write adds it only under DebugProfile.
+type DebugExtension struct {
+	Stack []StackFrame `json:"stack"`
+}
+
+func (DebugExtension) InternalExtension() {}
+
+// UnmarshalJSON decodes only extensions that have a "stack" member.
+func (e *DebugExtension) UnmarshalJSON(data []byte) error {
+	type plain DebugExtension
+	return unmarshalWithMember(data, "stack", (*plain)(e))
+}
+
+func init() {
+	RegisterExtension(DebugExtension{})
+}
+
+// CallerCaptureArgs configures a CallerCapture.
+type CallerCaptureArgs struct {
+	// Depth is the maximum number of frames kept; DefaultStackDepth when
+	// zero.
+	Depth int
+	// Keep, when set, selects the frames to keep; DefaultStackFilter when
+	// nil. Frames of this package are always dropped.
+	Keep func(StackFrame) bool
+}
+
+// CallerCapture records where problems are constructed.
+type CallerCapture struct {
+	depth int
+	keep  func(StackFrame) bool
+}
+
+func NewCallerCapture(args CallerCaptureArgs) *CallerCapture {
+	c := &CallerCapture{depth: args.Depth, keep: args.Keep}
+	if c.depth <= 0 {
+		c.depth = DefaultStackDepth
+	}
+	if c.keep == nil {
+		c.keep = DefaultStackFilter
+	}
+	return c
+}
+
+// DefaultStackFilter drops Go runtime, testing and net/http server frames,
+// which say nothing about where a problem originated.
+func DefaultStackFilter(f StackFrame) bool {
+	for _, prefix := range []string{"runtime.", "testing.", "net/http."} {
+		if strings.HasPrefix(f.Function, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Capture returns the stack of its caller, skipping skip further frames.
+func (c *CallerCapture) Capture(skip int) []StackFrame {
+	// Allow for dropped frames when sizing the buffer
+	pcs := make([]uintptr, c.depth+16)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]StackFrame, 0, c.depth)
+	for len(stack) < c.depth {
+		frame, more := frames.Next()
+		f := StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line}
+		if !strings.HasPrefix(f.Function, packagePrefix) && c.keep(f) {
+			stack = append(stack, f)
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+var callerCapture struct {
+	sync.RWMutex
+	c *CallerCapture
+}
+
+// SetCallerCapture makes NewResponse record its caller's stack; nil, the
+// default, disables capture.
+func SetCallerCapture(c *CallerCapture) {
+	callerCapture.Lock()
+	defer callerCapture.Unlock()
+	callerCapture.c = c
+}
+
+func GetCallerCapture() *CallerCapture {
+	callerCapture.RLock()
+	defer callerCapture.RUnlock()
+	return callerCapture.c
+}
+
+// Stack returns the frames captured by NewResponse, if capture was enabled.
+func (r *Response) Stack() []StackFrame {
+	return r.stack
+}
+
+// withDebugExtension returns r with its stack appended as a DebugExtension.
+func (r *Response) withDebugExtension() *Response {
+	if len(r.stack) == 0 {
+		return r
+	}
+	out := *r
+	out.Extensions = append(append([]Extension(nil), r.Extensions...), DebugExtension{Stack: r.stack})
+	return &out
+}
+
+// withStackAttr appends the captured stack, if any, to log arguments.
+func (r *Response) withStackAttr(args ...any) []any {
+	if len(r.stack) == 0 {
+		return args
+	}
+	return append(args, "stack", r.stack)
+}
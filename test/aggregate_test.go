@@ -0,0 +1,200 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func problem(typ rfc9457.ErrorTypeURI, status int, detail string) *rfc9457.Response {
+	return rfc9457.NewResponse(rfc9457.ResponseArgs{Type: typ, Title: string(typ), Status: status, Detail: detail})
+}
+
+func TestAggregate_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     int
+	}{
+		{"highest_5xx", []int{404, 503, 500}, 503},
+		{"specific_4xx_over_400", []int{400, 404}, 404},
+		{"highest_specific_4xx", []int{422, 404, 400}, 422},
+		{"only_400", []int{400, 400}, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var problems []*rfc9457.Response
+			for _, status := range tt.statuses {
+				problems = append(problems, problem(rfc9457.AboutBlankErrorType, status, ""))
+			}
+			got := rfc9457.Aggregate(rfc9457.AggregateArgs{}, problems...)
+			if got.Status != tt.want {
+				t.Errorf("Status: got %d, want %d", got.Status, tt.want)
+			}
+			if got.Type != rfc9457.MultipleProblemsErrorType {
+				t.Errorf("Type: got %q", got.Type)
+			}
+		})
+	}
+
+	single := problem(rfc9457.NoResultsErrorType, 404, "")
+	if got := rfc9457.Aggregate(rfc9457.AggregateArgs{}, nil, single); got != single {
+		t.Errorf("Aggregate of one problem: got %v, want it unchanged", got)
+	}
+	if got := rfc9457.Aggregate(rfc9457.AggregateArgs{}); got != nil {
+		t.Errorf("Aggregate of none: got %v, want nil", got)
+	}
+}
+
+func TestAggregateErrors_RoundTrip(t *testing.T) {
+	notFound := problem(rfc9457.NoResultsErrorType, 404, "No user 42")
+	invalid := problem(rfc9457.InvalidParameterErrorType, 422, "id must be an integer")
+	err := errors.Join(
+		notFound,
+		errors.Join(invalid, fs.ErrPermission),
+	)
+	agg := rfc9457.AggregateErrors(rfc9457.AggregateArgs{
+		Map: func(err error) *rfc9457.Response {
+			if errors.Is(err, fs.ErrPermission) {
+				return problem(rfc9457.UnauthorizedErrorType, 401, "")
+			}
+			return nil
+		},
+	}, err, errors.New("disk on fire"))
+
+	if agg.Status != 500 {
+		t.Errorf("Status: got %d, want 500", agg.Status)
+	}
+	if !errors.Is(agg, fs.ErrPermission) {
+		t.Error("errors.Is does not reach the aggregated errors")
+	}
+
+	rec := httptest.NewRecorder()
+	if err := agg.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var decoded rfc9457.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	parts := decoded.Split()
+	want := []rfc9457.ErrorTypeURI{
+		rfc9457.NoResultsErrorType,
+		rfc9457.InvalidParameterErrorType,
+		rfc9457.UnauthorizedErrorType,
+		rfc9457.InternalServerErrorType,
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("Split: got %d parts, want %d: %+v", len(parts), len(want), decoded.Extensions)
+	}
+	for i, typ := range want {
+		if parts[i].Type != typ {
+			t.Errorf("Part %d: got %q, want %q", i, parts[i].Type, typ)
+		}
+	}
+	if parts[0].Detail != "No user 42" {
+		t.Errorf("Part 0 detail: got %q", parts[0].Detail)
+	}
+
+	// Aggregates are flattened rather than nested
+	nested := rfc9457.Aggregate(rfc9457.AggregateArgs{}, agg, problem(rfc9457.AboutBlankErrorType, 409, ""))
+	if got := len(nested.Split()); got != 5 {
+		t.Errorf("Nested aggregate: got %d parts, want 5", got)
+	}
+	if parts := notFound.Split(); len(parts) != 1 || parts[0] != notFound {
+		t.Errorf("Split of a plain problem: got %v", parts)
+	}
+}
+
+func TestAggregate_Redacted(t *testing.T) {
+	rfc9457.SetRedactionProfile(rfc9457.ProductionProfile)
+	defer rfc9457.SetRedactionProfile(rfc9457.DebugProfile)
+
+	agg := rfc9457.Aggregate(rfc9457.AggregateArgs{},
+		problem(rfc9457.QueryFailedErrorType, 500, "SELECT * FROM users"),
+		problem(rfc9457.NoResultsErrorType, 404, "No user 42"),
+	)
+	rec := httptest.NewRecorder()
+	if err := agg.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var decoded rfc9457.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	parts := decoded.Split()
+	if len(parts) != 2 || parts[0].Detail != "" || parts[1].Detail != "No user 42" {
+		t.Errorf("Redacted parts: got %s", rec.Body.String())
+	}
+}
+
+func TestAggregate_RedactedAndScrubbed(t *testing.T) {
+	rfc9457.SetRedactionProfile(rfc9457.ProductionProfile)
+	defer rfc9457.SetRedactionProfile(rfc9457.DebugProfile)
+	rfc9457.SetScrubber(rfc9457.NewScrubber(rfc9457.DefaultScrubRules()...))
+	defer rfc9457.SetScrubber(nil)
+
+	agg := rfc9457.Aggregate(rfc9457.AggregateArgs{},
+		problem(rfc9457.InvalidDBQueryErrorType, 400, "SELECT * FROM users WHERE email = 'jane@example.com'"),
+		problem(rfc9457.NoResultsErrorType, 404, "No user jane@example.com"),
+	)
+	tests := []struct {
+		name  string
+		write func(rec *httptest.ResponseRecorder) error
+		parse func(data []byte) (*rfc9457.Response, error)
+	}{
+		{
+			name:  "response",
+			write: func(rec *httptest.ResponseRecorder) error { return agg.Write(rec) },
+			parse: func(data []byte) (*rfc9457.Response, error) {
+				var decoded rfc9457.Response
+				err := json.Unmarshal(data, &decoded)
+				return &decoded, err
+			},
+		},
+		{
+			name: "batch_item",
+			write: func(rec *httptest.ResponseRecorder) error {
+				var b rfc9457.BatchResult
+				b.Fail("1", agg)
+				return b.Write(rec)
+			},
+			parse: func(data []byte) (*rfc9457.Response, error) {
+				var envelope struct {
+					Items []struct {
+						Problem *rfc9457.Response `json:"problem"`
+					} `json:"items"`
+				}
+				err := json.Unmarshal(data, &envelope)
+				if err != nil {
+					return nil, err
+				}
+				return envelope.Items[0].Problem, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if err := tt.write(rec); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if strings.Contains(rec.Body.String(), "SELECT") {
+				t.Errorf("Sensitive detail of an aggregated problem was sent: %s", rec.Body.String())
+			}
+			decoded, err := tt.parse(rec.Body.Bytes())
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			parts := decoded.Split()
+			if len(parts) != 2 || parts[0].Detail == "" || parts[1].Detail != "No user [email]" {
+				t.Errorf("Parts: got %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+var errUserNotFound = errors.New("user not found")
+
+const userNotFoundErrorType rfc9457.ErrorTypeURI = "https://example.com/errors/user-not-found"
+
+func TestBatchResult_RoundTrip(t *testing.T) {
+	rfc9457.RegisterProblemError(userNotFoundErrorType, func(*rfc9457.Response) error { return errUserNotFound })
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var b rfc9457.BatchResult
+		b.Succeed("1", user{Name: "Ada"})
+		if req.URL.Query().Get("fail") != "" {
+			b.Fail("2", problem(userNotFoundErrorType, 404, "No user 2"))
+		}
+		if err := b.Write(w); err != nil {
+			t.Errorf("Write: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantFailed int
+	}{
+		{"all_succeeded", "", http.StatusOK, 0},
+		{"partial_failure", "?fail=1", http.StatusMultiStatus, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + tt.query)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Status: got %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			b, err := rfc9457.ParseBatchResult(resp)
+			if err != nil {
+				t.Fatalf("ParseBatchResult: %v", err)
+			}
+			if len(b.Failed()) != tt.wantFailed {
+				t.Errorf("Failed: got %d, want %d", len(b.Failed()), tt.wantFailed)
+			}
+			var u user
+			if err := b.Items[0].DecodeResult(&u); err != nil || u.Name != "Ada" {
+				t.Errorf("DecodeResult: got %+v, %v", u, err)
+			}
+			if tt.wantFailed == 0 {
+				if err := b.Err(); err != nil {
+					t.Errorf("Err: got %v, want nil", err)
+				}
+				return
+			}
+			var itemErr *rfc9457.BatchItemError
+			if !errors.As(b.Err(), &itemErr) || itemErr.ID != "2" {
+				t.Fatalf("Err: got %v, want a BatchItemError for item 2", b.Err())
+			}
+			if !errors.Is(itemErr, errUserNotFound) {
+				t.Errorf("Item error does not map to the domain error: %v", itemErr)
+			}
+			var p *rfc9457.Response
+			if !errors.As(itemErr, &p) || p.Detail != "No user 2" || p.HTTPResponse() != resp {
+				t.Errorf("Item problem: got %+v", p)
+			}
+		})
+	}
+}
+
+func TestParseBatchResult_WholeRequestFailed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	_ = problem(rfc9457.UnauthorizedErrorType, 401, "").Write(rec)
+
+	_, err := rfc9457.ParseBatchResult(rec.Result())
+	var p *rfc9457.Response
+	if !errors.As(err, &p) || p.Status != 401 {
+		t.Errorf("ParseBatchResult: got %v, want the 401 problem", err)
+	}
+}
+
+func TestBatchResult_FailNil(t *testing.T) {
+	var b rfc9457.BatchResult
+	b.Fail("1", nil)
+	item := b.Items[0]
+	if item.Status != http.StatusInternalServerError || item.Problem == nil ||
+		item.Problem.Type != rfc9457.AboutBlankErrorType || item.Problem.Status != http.StatusInternalServerError {
+		t.Errorf("Item: got %+v", item)
+	}
+	if err := b.Write(httptest.NewRecorder()); err != nil {
+		t.Errorf("Write: %v", err)
+	}
+}
+
+func TestBatchResult_WriteEncodeError(t *testing.T) {
+	var written int
+	rfc9457.AddObserver(rfc9457.ProblemWrittenFunc(func(context.Context, *rfc9457.Response, int, time.Duration) {
+		written++
+	}))
+	defer rfc9457.ResetObservers()
+
+	var b rfc9457.BatchResult
+	b.Succeed("1", make(chan int))
+	b.Fail("2", problem(rfc9457.NoResultsErrorType, 404, "No user 2"))
+	if err := b.Write(httptest.NewRecorder()); err == nil {
+		t.Fatal("Write: got nil, want an encoding error")
+	}
+	if written != 0 {
+		t.Errorf("Observers were notified %d times for an envelope that was not written", written)
+	}
+}
+
+func TestBatchResult_FailInvalidStatus(t *testing.T) {
+	var b rfc9457.BatchResult
+	b.Fail("1", problem(rfc9457.NoResultsErrorType, http.StatusOK, "No user 1"))
+	rec := httptest.NewRecorder()
+	if err := b.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var envelope struct {
+		Items []struct {
+			Status  int `json:"status"`
+			Problem struct {
+				Status int `json:"status"`
+			} `json:"problem"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	item := envelope.Items[0]
+	if item.Status != http.StatusInternalServerError || item.Problem.Status != http.StatusInternalServerError {
+		t.Errorf("Item status %d and problem status %d, want both 500", item.Status, item.Problem.Status)
+	}
+}
+
+func TestBatchResult_WriteLogsAndRecords(t *testing.T) {
+	buf := captureLogs(t)
+	rfc9457.SetRedactionProfile(rfc9457.ProductionProfile)
+	defer rfc9457.SetRedactionProfile(rfc9457.DebugProfile)
+	store := rfc9457.NewMemoryOccurrenceStore(10)
+	rfc9457.SetOccurrenceStore(store)
+	defer rfc9457.SetOccurrenceStore(nil)
+
+	p := problem(rfc9457.QueryFailedErrorType, 500, "SELECT * FROM users")
+	p.OccurrenceID = "batch-1"
+	p.Instance = "urn:uuid:batch-1"
+	var b rfc9457.BatchResult
+	b.Fail("1", p)
+	rec := httptest.NewRecorder()
+	if err := b.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if strings.Contains(rec.Body.String(), "SELECT") {
+		t.Errorf("Sensitive detail was sent: %s", rec.Body.String())
+	}
+	entries := logEntries(t, buf, "Redacted problem details")
+	if len(entries) != 1 || entries[0]["detail"] != "SELECT * FROM users" {
+		t.Errorf("Redaction log: got %v", entries)
+	}
+	got, err := store.LoadOccurrence(context.Background(), "batch-1")
+	if err != nil {
+		t.Fatalf("LoadOccurrence: %v", err)
+	}
+	if got.Response.Detail != "SELECT * FROM users" {
+		t.Errorf("Recorded detail: got %q", got.Response.Detail)
+	}
+}
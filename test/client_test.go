@@ -0,0 +1,175 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func newHTTPResponse(status int, contentType, body string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	if contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	return resp
+}
+
+func TestParseHTTPResponse(t *testing.T) {
+	problem := &rfc9457.Response{
+		Type:     rfc9457.NoResultsErrorType,
+		Title:    "No Results",
+		Status:   404,
+		Detail:   "No user with ID 42",
+		Instance: "/api/users/42",
+	}
+	problemXML, err := rfc9457.MarshalProblemXML(problem)
+	if err != nil {
+		t.Fatalf("MarshalProblemXML: %v", err)
+	}
+	problemJSON := `{"type":"https://schema.xmlui.org/errors/test-server/api/database/no-results","title":"No Results","status":404,"detail":"No user with ID 42","instance":"/api/users/42"}`
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        *rfc9457.Response
+	}{
+		{
+			name:        "problem_json",
+			status:      404,
+			contentType: "application/problem+json; charset=utf-8",
+			body:        problemJSON,
+			want:        problem,
+		},
+		{
+			name:        "problem_xml",
+			status:      404,
+			contentType: "application/problem+xml",
+			body:        string(problemXML),
+			want:        problem,
+		},
+		{
+			name:        "plain_json_with_problem_shape",
+			status:      404,
+			contentType: "application/json",
+			body:        problemJSON,
+			want:        problem,
+		},
+		{
+			name:        "problem_json_without_status",
+			status:      409,
+			contentType: "application/problem+json",
+			body:        `{"title":"Conflict"}`,
+			want:        &rfc9457.Response{Type: rfc9457.AboutBlankErrorType, Title: "Conflict", Status: 409},
+		},
+		{
+			name:        "plain_json_other_shape",
+			status:      500,
+			contentType: "application/json",
+			body:        `{"error":"boom"}`,
+			want:        &rfc9457.Response{Type: rfc9457.AboutBlankErrorType, Title: "Internal Server Error", Status: 500, Detail: `{"error":"boom"}`},
+		},
+		{
+			name:        "html_error_page",
+			status:      502,
+			contentType: "text/html",
+			body:        "<html>\n  <body>Bad gateway</body>\n</html>",
+			want:        &rfc9457.Response{Type: rfc9457.AboutBlankErrorType, Title: "Bad Gateway", Status: 502, Detail: "<html> <body>Bad gateway</body> </html>"},
+		},
+		{
+			name:        "binary_error_body",
+			status:      503,
+			contentType: "application/octet-stream",
+			body:        "\x00\x01",
+			want:        &rfc9457.Response{Type: rfc9457.AboutBlankErrorType, Title: "Service Unavailable", Status: 503},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := newHTTPResponse(tt.status, tt.contentType, tt.body)
+			got, err := rfc9457.ParseHTTPResponse(resp)
+			if err != nil {
+				t.Fatalf("ParseHTTPResponse: %v", err)
+			}
+			assertRFC9457ErrorEqual(t, got, tt.want)
+
+			// The body remains readable after parsing
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.body {
+				t.Errorf("Body after parse: got %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestParseHTTPResponse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *http.Response
+		limit   int64
+		wantErr error
+	}{
+		{
+			name:    "success_not_problem",
+			resp:    newHTTPResponse(200, "text/plain", "ok"),
+			limit:   rfc9457.DefaultMaxProblemBodySize,
+			wantErr: rfc9457.ErrNotProblem,
+		},
+		{
+			name:    "body_too_large",
+			resp:    newHTTPResponse(500, "application/problem+json", `{"title":"`+strings.Repeat("x", 100)+`"}`),
+			limit:   64,
+			wantErr: rfc9457.ErrProblemBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rfc9457.ParseHTTPResponseLimited(tt.resp, tt.limit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseHTTPResponseLimited: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProblemXML_Extensions(t *testing.T) {
+	problem := &rfc9457.Response{
+		Type:   rfc9457.ConstraintViolationErrorType,
+		Title:  "Constraint Violation",
+		Status: 422,
+		Extensions: []rfc9457.Extension{
+			map[string]any{"parameter": "score", "allowed": []any{"0", "100"}},
+		},
+	}
+	data, err := rfc9457.MarshalProblemXML(problem)
+	if err != nil {
+		t.Fatalf("MarshalProblemXML: %v", err)
+	}
+	if !strings.Contains(string(data), `<problem xmlns="urn:ietf:rfc:7807">`) {
+		t.Errorf("Missing RFC 9457 namespace:\n%s", data)
+	}
+
+	var got rfc9457.Response
+	if err := rfc9457.UnmarshalProblemXML(data, &got); err != nil {
+		t.Fatalf("UnmarshalProblemXML: %v", err)
+	}
+	assertRFC9457ErrorEqual(t, &got, problem)
+	if len(got.Extensions) != 1 {
+		t.Fatalf("Extensions: got %d, want 1", len(got.Extensions))
+	}
+	ext, ok := got.Extensions[0].(map[string]any)
+	if !ok || ext["parameter"] != "score" {
+		t.Errorf("Extension: got %#v", got.Extensions[0])
+	}
+}
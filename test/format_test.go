@@ -0,0 +1,72 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func TestResponse_Format(t *testing.T) {
+	cause := fmt.Errorf("loading user: %w", fs.ErrNotExist)
+	resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:   rfc9457.NoResultsErrorType,
+		Title:  "No Results",
+		Status: 404,
+		Detail: `No user "abc" found`,
+		Extensions: []rfc9457.Extension{
+			map[string]any{"user_id": "abc", "attempts": 2},
+		},
+		Cause: cause,
+	})
+
+	oneLine := fmt.Sprintf("404 %s: No Results", rfc9457.NoResultsErrorType)
+	if got := fmt.Sprintf("%v", resp); got != oneLine {
+		t.Errorf("%%v: got %q, want %q", got, oneLine)
+	}
+	if got := fmt.Sprintf("%q", resp); got != fmt.Sprintf("%q", oneLine) {
+		t.Errorf("%%q: got %s", got)
+	}
+	if !errors.Is(resp, fs.ErrNotExist) {
+		t.Error("errors.Is does not reach the cause")
+	}
+
+	want := map[string]string{
+		"status":              "404",
+		"type":                string(rfc9457.NoResultsErrorType),
+		"title":               "No Results",
+		"detail":              `No user "abc" found`,
+		"extensions.attempts": "2",
+		"extensions.user_id":  "abc",
+		"cause.0":             "loading user: file does not exist",
+		"cause.1":             "file does not exist",
+	}
+	for _, verb := range []string{"%v", "%q", "%+v"} {
+		t.Run(verb, func(t *testing.T) {
+			fields, err := rfc9457.ParseFields(fmt.Sprintf(verb, resp))
+			if err != nil {
+				t.Fatalf("ParseFields: %v", err)
+			}
+			for key, value := range want {
+				if verb != "%+v" && key != "status" && key != "type" && key != "title" {
+					continue
+				}
+				if fields[key] != value {
+					t.Errorf("%s: got %q, want %q", key, fields[key], value)
+				}
+			}
+			if verb == "%+v" && len(fields) != len(want) {
+				t.Errorf("Fields: got %v", fields)
+			}
+		})
+	}
+
+	if _, err := rfc9457.ParseFields("not a problem"); err == nil {
+		t.Error("ParseFields accepted unformatted text")
+	}
+	if got := fmt.Sprintf("%v", (*rfc9457.Response)(nil)); got != "<nil>" {
+		t.Errorf("nil: got %q", got)
+	}
+}
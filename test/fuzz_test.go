@@ -13,7 +13,7 @@ import (
 )
 
 func init() {
-	// Set up a logger for tests to prevent panics
+	// Keep test output to errors
 	rfc9457.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelError,
 	})))
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"flag"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/rfc9457test"
+)
+
+// A test package's own -update flag must not clash with rfc9457test's.
+var _ = flag.Bool("update", false, "unused; checks that rfc9457test does not define -update")
+
+func TestCanonicalizeJSON(t *testing.T) {
+	got, err := rfc9457.CanonicalizeJSON([]byte(`{"status":404,"type":"about:blank","instance":"urn:uuid:0190b6c4-8f3e-7a2b-9c1d-2e3f4a5b6c7d","extensions":[{"at":"2026-10-18T12:00:00.5Z","id":"01JAB2C3D4E5F6G7H8J9K0MNPQ"}]}`))
+	if err != nil {
+		t.Fatalf("CanonicalizeJSON: %v", err)
+	}
+	want := `{
+  "extensions": [
+    {
+      "at": "<timestamp>",
+      "id": "<ulid>"
+    }
+  ],
+  "instance": "urn:uuid:<uuid>",
+  "status": 404,
+  "type": "about:blank"
+}
+`
+	if string(got) != want {
+		t.Errorf("CanonicalizeJSON:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	kept, err := rfc9457.Canonicalizer{KeepIDs: true}.JSON([]byte(`{"instance":"urn:uuid:0190b6c4-8f3e-7a2b-9c1d-2e3f4a5b6c7d"}`))
+	if err != nil || !strings.Contains(string(kept), "0190b6c4") {
+		t.Errorf("Canonicalizer{KeepIDs: true}: got %s, %v", kept, err)
+	}
+
+	if _, err := rfc9457.CanonicalizeJSON([]byte(`{} {}`)); err == nil {
+		t.Errorf("CanonicalizeJSON accepted trailing data")
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	resp := &rfc9457.Response{
+		Type:     rfc9457.NoResultsErrorType,
+		Title:    "No Results",
+		Status:   404,
+		Detail:   "No user with ID 42",
+		Instance: "urn:uuid:0190b6c4-8f3e-7a2b-9c1d-2e3f4a5b6c7d",
+	}
+	rfc9457test.AssertGolden(t, "no_results", resp)
+
+	rec := httptest.NewRecorder()
+	if err := resp.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	rfc9457test.AssertGoldenRecorder(t, "no_results", rec)
+
+	if f := flag.Lookup("rfc9457test.update"); f.Value.String() == "true" {
+		return
+	}
+	tb := &recordingTB{TB: t}
+	rfc9457test.AssertGolden(tb, "no_results", &rfc9457.Response{Type: rfc9457.NoResultsErrorType, Status: 404})
+	if len(tb.errors) != 1 {
+		t.Errorf("AssertGolden on mismatch: got %d errors, want 1", len(tb.errors))
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/rfc9457har"
+)
+
+// harEntry builds a HAR log entry; body is base64-encoded when encode is set.
+func harEntry(method, url string, status int, contentType, body string, encode bool) map[string]any {
+	content := map[string]any{"mimeType": contentType, "text": body}
+	if encode {
+		content["text"] = base64.StdEncoding.EncodeToString([]byte(body))
+		content["encoding"] = "base64"
+	}
+	return map[string]any{
+		"request": map[string]any{"method": method, "url": url},
+		"response": map[string]any{
+			"status":  status,
+			"headers": []any{map[string]any{"name": "content-type", "value": contentType}},
+			"content": content,
+		},
+	}
+}
+
+func TestAnalyzeHAR(t *testing.T) {
+	noResults := `{"type":"` + string(rfc9457.NoResultsErrorType) + `","title":"No Results","status":404}`
+	entries := []any{
+		harEntry("GET", "https://api.example.com/users/42", 200, "application/json", `{"id":42}`, false),
+		harEntry("GET", "https://api.example.com/users/7", 404, "application/problem+json", noResults, false),
+		harEntry("GET", "https://api.example.com/users/8", 404, "application/problem+json", noResults, true),
+		harEntry("GET", "https://api.example.com/users/9", 500, "application/problem+json", noResults, false),
+		harEntry("POST", "https://api.example.com/orders", 400, "application/problem+json", `{"type":"https://example.com/unlisted","title":"Bad","status":400}`, false),
+		harEntry("POST", "https://api.example.com/orders", 500, "text/html", "<h1>Oops</h1>", false),
+		harEntry("DELETE", "https://api.example.com/orders/0190b6c4-8f3e-7a2b-9c1d-2e3f4a5b6c7d", 500, "application/problem+json",
+			`{"title":"Internal Server Error","status":500,"detail":"pq: syntax error at or near \"FROM\""}`, false),
+	}
+	data, err := json.Marshal(map[string]any{"log": map[string]any{"version": "1.2", "entries": entries}})
+	if err != nil {
+		t.Fatalf("Marshal HAR: %v", err)
+	}
+
+	report, err := (&rfc9457har.Analyzer{}).Analyze(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if report.ErrorResponses != 6 {
+		t.Errorf("ErrorResponses: got %d, want 6", report.ErrorResponses)
+	}
+
+	type finding struct {
+		entry int
+		kind  rfc9457har.FindingKind
+	}
+	var got []finding
+	for _, f := range report.Findings {
+		got = append(got, finding{f.Entry, f.Kind})
+	}
+	want := []finding{
+		{3, rfc9457har.StatusMismatch},
+		{4, rfc9457har.UnknownType},
+		{5, rfc9457har.NonCompliant},
+		{6, rfc9457har.LeakedDetail},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Findings: got %v, want %v", report.Findings, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Finding %d: got %v, want %v (%s)", i, got[i], want[i], report.Findings[i])
+		}
+	}
+
+	var endpoints []string
+	for _, e := range report.Endpoints {
+		endpoints = append(endpoints, e.Method+" "+e.Path)
+	}
+	if strings.Join(endpoints, ", ") != "POST /orders, DELETE /orders/{id}, GET /users/{id}" {
+		t.Errorf("Endpoints: got %v", endpoints)
+	}
+	users := report.Endpoints[2]
+	if len(users.Types) != 2 || users.Types[0].Count != 2 || users.Types[0].Status != 404 {
+		t.Errorf("GET /users/{id} types: got %+v", users.Types)
+	}
+
+	var sb strings.Builder
+	if err := report.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	if !strings.Contains(sb.String(), "6 error responses, 4 findings") {
+		t.Errorf("Report:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+var (
+	uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+func TestIDGenerators(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     rfc9457.IDGenerator
+		pattern *regexp.Regexp
+	}{
+		{"uuid_v4", rfc9457.UUIDv4Generator, uuidV4Pattern},
+		{"uuid_v7", rfc9457.UUIDv7Generator, uuidV7Pattern},
+		{"ulid", rfc9457.ULIDGenerator, ulidPattern},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[string]bool)
+			for range 100 {
+				id := tt.gen.GenerateID(nil)
+				if !tt.pattern.MatchString(id) {
+					t.Fatalf("ID %q does not match %s", id, tt.pattern)
+				}
+				if seen[id] {
+					t.Fatalf("Duplicate ID %q", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestRequestIDGenerator(t *testing.T) {
+	gen := rfc9457.RequestIDGenerator("", rfc9457.ULIDGenerator)
+
+	req := httptest.NewRequest("GET", "/api/users/abc", nil)
+	req.Header.Set(rfc9457.RequestIDHeader, "req-123")
+	if got := gen.GenerateID(req); got != "req-123" {
+		t.Errorf("GenerateID with header: got %q, want %q", got, "req-123")
+	}
+
+	for _, header := range []string{"", "a/b?c#d", "id with spaces", strings.Repeat("x", rfc9457.MaxRequestIDLength+1)} {
+		req = httptest.NewRequest("GET", "/api/users/abc", nil)
+		if header != "" {
+			req.Header.Set(rfc9457.RequestIDHeader, header)
+		}
+		if got := gen.GenerateID(req); !ulidPattern.MatchString(got) {
+			t.Errorf("GenerateID with header %q: got %q, want ULID fallback", header, got)
+		}
+	}
+}
+
+func TestInstanceGenerator_NewOccurrence(t *testing.T) {
+	fixed := rfc9457.IDGeneratorFunc(func(*http.Request) string { return "abc123" })
+
+	tests := []struct {
+		name string
+		args rfc9457.InstanceArgs
+		want string
+	}{
+		{
+			name: "custom_urn_namespace",
+			args: rfc9457.InstanceArgs{IDGenerator: fixed, URNNamespace: "ulid"},
+			want: "urn:ulid:abc123",
+		},
+		{
+			name: "occurrences_root_url",
+			args: rfc9457.InstanceArgs{IDGenerator: fixed, OccurrencesRootURI: "https://api.example.com/problems/"},
+			want: "https://api.example.com/problems/abc123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := rfc9457.NewInstanceGenerator(tt.args)
+			if err != nil {
+				t.Fatalf("NewInstanceGenerator: %v", err)
+			}
+			got := g.NewOccurrence(nil)
+			if got.ID != "abc123" {
+				t.Errorf("ID: got %q, want %q", got.ID, "abc123")
+			}
+			if got.Instance != tt.want {
+				t.Errorf("Instance: got %q, want %q", got.Instance, tt.want)
+			}
+			id, ok := g.OccurrenceID(got.Instance)
+			if !ok || id != "abc123" {
+				t.Errorf("OccurrenceID: got %q, %v, want %q, true", id, ok, "abc123")
+			}
+		})
+	}
+}
+
+func TestNewInstanceGenerator_URNNamespace(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       rfc9457.InstanceArgs
+		wantPrefix string
+		wantErr    error
+	}{
+		{"default_generator", rfc9457.InstanceArgs{}, "urn:uuid:", nil},
+		{"uuid_v7", rfc9457.InstanceArgs{IDGenerator: rfc9457.UUIDv7Generator}, "urn:uuid:", nil},
+		{"ulid_without_namespace", rfc9457.InstanceArgs{IDGenerator: rfc9457.ULIDGenerator}, "", rfc9457.ErrNoURNNamespace},
+		{"request_id_without_namespace", rfc9457.InstanceArgs{IDGenerator: rfc9457.RequestIDGenerator("", nil)}, "", rfc9457.ErrNoURNNamespace},
+		{"ulid_with_namespace", rfc9457.InstanceArgs{IDGenerator: rfc9457.ULIDGenerator, URNNamespace: "example"}, "urn:example:", nil},
+		{"ulid_with_root_uri", rfc9457.InstanceArgs{IDGenerator: rfc9457.ULIDGenerator, OccurrencesRootURI: "https://api.example.com/problems"}, "https://api.example.com/problems/", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := rfc9457.NewInstanceGenerator(tt.args)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewInstanceGenerator: got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := g.NewOccurrence(nil).Instance; !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("Instance: got %q, want prefix %q", got, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestNewResponse_AssignsInstance(t *testing.T) {
+	g, err := rfc9457.NewInstanceGenerator(rfc9457.InstanceArgs{
+		OccurrencesRootURI: "https://api.example.com/problems",
+	})
+	if err != nil {
+		t.Fatalf("NewInstanceGenerator: %v", err)
+	}
+	rfc9457.SetInstanceGenerator(g)
+	defer rfc9457.SetInstanceGenerator(nil)
+
+	req := httptest.NewRequest("GET", "/api/users/abc", nil)
+	got := rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:    rfc9457.InvalidParameterErrorType,
+		Title:   "Invalid Parameter Type",
+		Status:  422,
+		Request: req,
+	})
+
+	if !uuidV4Pattern.MatchString(got.OccurrenceID) {
+		t.Errorf("OccurrenceID: got %q, want UUIDv4", got.OccurrenceID)
+	}
+	if !strings.HasSuffix(got.Instance, "/"+got.OccurrenceID) {
+		t.Errorf("Instance: got %q, want suffix %q", got.Instance, got.OccurrenceID)
+	}
+
+	// An explicit Instance is never overridden
+	got = rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:     rfc9457.InvalidParameterErrorType,
+		Status:   422,
+		Instance: "/api/users/abc",
+		Request:  req,
+	})
+	if got.Instance != "/api/users/abc" || got.OccurrenceID != "" {
+		t.Errorf("Instance: got %q (id %q), want %q", got.Instance, got.OccurrenceID, "/api/users/abc")
+	}
+}
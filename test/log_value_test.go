@@ -0,0 +1,119 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// captureLogs routes the package logger to a JSON buffer for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := rfc9457.Logger()
+	rfc9457.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { rfc9457.SetLogger(previous) })
+	return &buf
+}
+
+// logEntries decodes JSON log lines, keeping those with message msg.
+func logEntries(t *testing.T, buf *bytes.Buffer, msg string) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Log line is not JSON: %v\n%s", err, line)
+		}
+		if entry["msg"] == msg {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestResponse_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	resp := &rfc9457.Response{
+		Type:       rfc9457.NoResultsErrorType,
+		Title:      "No Results",
+		Status:     404,
+		Detail:     "No user with ID 42",
+		Instance:   "/api/users/42",
+		Extensions: []rfc9457.Extension{rfc9457.RetryableExtension{Retryable: false}},
+	}
+	logger.Info("failed", "problem", resp)
+
+	entries := logEntries(t, &buf, "failed")
+	if len(entries) != 1 {
+		t.Fatalf("Log entries: got %d, want 1", len(entries))
+	}
+	problem, ok := entries[0]["problem"].(map[string]any)
+	if !ok {
+		t.Fatalf("problem attribute is not a group: %v", entries[0]["problem"])
+	}
+	want := map[string]any{
+		"type":       string(rfc9457.NoResultsErrorType),
+		"title":      "No Results",
+		"status":     float64(404),
+		"detail":     "No user with ID 42",
+		"instance":   "/api/users/42",
+		"extensions": map[string]any{"retryable": false},
+	}
+	for key, value := range want {
+		got, _ := json.Marshal(problem[key])
+		w, _ := json.Marshal(value)
+		if string(got) != string(w) {
+			t.Errorf("problem.%s: got %s, want %s", key, got, w)
+		}
+	}
+}
+
+func TestLogObserver(t *testing.T) {
+	buf := captureLogs(t)
+	rfc9457.AddObserver(rfc9457.NewLogObserver(rfc9457.LogObserverArgs{
+		SampleEvery: map[rfc9457.ErrorTypeURI]int{rfc9457.NoResultsErrorType: 3},
+	}))
+	defer rfc9457.ResetObservers()
+
+	write := func(resp *rfc9457.Response) {
+		if err := resp.Write(httptest.NewRecorder()); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	for range 7 {
+		write(&rfc9457.Response{Type: rfc9457.NoResultsErrorType, Title: "No Results", Status: 404})
+	}
+	write(&rfc9457.Response{Type: rfc9457.MissingParametersErrorType, Title: "Missing Required Parameters", Status: 400})
+	write(&rfc9457.Response{Type: rfc9457.InternalServerErrorType, Title: "Internal Server Error", Status: 0})
+
+	entries := logEntries(t, buf, "Problem written")
+	var got []string
+	for _, entry := range entries {
+		problem := entry["problem"].(map[string]any)
+		got = append(got, entry["level"].(string)+" "+problem["title"].(string))
+	}
+	want := []string{
+		"INFO No Results", "INFO No Results", "INFO No Results",
+		"INFO Missing Required Parameters",
+		"ERROR Internal Server Error",
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Logged problems:\ngot  %v\nwant %v", got, want)
+	}
+	if entries[0]["sampled_every"] != float64(3) {
+		t.Errorf("sampled_every: got %v, want 3", entries[0]["sampled_every"])
+	}
+	if status := entries[len(entries)-1]["http_status"]; status != float64(500) {
+		t.Errorf("http_status of normalized problem: got %v, want 500", status)
+	}
+}
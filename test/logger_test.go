@@ -0,0 +1,104 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func TestLogger_DefaultsWithoutSetLogger(t *testing.T) {
+	previous := rfc9457.Logger()
+	rfc9457.SetLogger(nil)
+	defer rfc9457.SetLogger(previous)
+
+	if rfc9457.Logger() != slog.Default() {
+		t.Errorf("Logger without SetLogger is not slog.Default()")
+	}
+	var got rfc9457.Response
+	err := json.Unmarshal([]byte(`{"title":"Bad","status":400,"extensions":["not an object",{"a":1}]}`), &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+}
+
+func TestDecodeFailurePolicy(t *testing.T) {
+	const doc = `{"title":"Bad","status":400,"extensions":["secret-token",{"email":"jane@example.com"}]}`
+
+	tests := []struct {
+		name     string
+		policy   rfc9457.DecodeFailurePolicy
+		levels   []string
+		withData bool
+	}{
+		{name: "default", policy: rfc9457.LogDecodeFailures, levels: []string{"WARN", "DEBUG"}},
+		{name: "with_data", policy: rfc9457.LogDecodeFailuresWithData, levels: []string{"WARN", "DEBUG"}, withData: true},
+		{name: "silent", policy: rfc9457.SilenceDecodeFailures},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			rfc9457.SetComponentLogger(rfc9457.DecodeComponent,
+				slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+			defer rfc9457.SetComponentLogger(rfc9457.DecodeComponent, nil)
+			rfc9457.SetDecodeFailurePolicy(tt.policy)
+			defer rfc9457.SetDecodeFailurePolicy(rfc9457.LogDecodeFailures)
+
+			var got rfc9457.Response
+			if err := json.Unmarshal([]byte(doc), &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			var levels []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("Log line: %v", err)
+				}
+				levels = append(levels, entry["level"].(string))
+				if _, hasData := entry["data"]; hasData != tt.withData {
+					t.Errorf("Entry %q has data: %v, want %v", entry["msg"], hasData, tt.withData)
+				}
+			}
+			if strings.Join(levels, ",") != strings.Join(tt.levels, ",") {
+				t.Errorf("Logged levels: got %v, want %v", levels, tt.levels)
+			}
+		})
+	}
+}
+
+func TestLoggerFrom_Context(t *testing.T) {
+	var ctxBuf, writeBuf bytes.Buffer
+	rfc9457.SetComponentLogger(rfc9457.WriteComponent, slog.New(slog.NewTextHandler(&writeBuf, nil)))
+	defer rfc9457.SetComponentLogger(rfc9457.WriteComponent, nil)
+
+	req := httptest.NewRequest("GET", "/api/users/42", nil)
+	req = req.WithContext(rfc9457.WithLogger(req.Context(), slog.New(slog.NewTextHandler(&ctxBuf, nil))))
+	resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:    rfc9457.InternalServerErrorType,
+		Title:   "Internal Server Error",
+		Status:  0,
+		Request: req,
+	})
+	if err := resp.Write(httptest.NewRecorder()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(ctxBuf.String(), "Normalized invalid problem status") {
+		t.Errorf("Context logger did not receive the warning: %q", ctxBuf.String())
+	}
+	if writeBuf.Len() != 0 {
+		t.Errorf("Component logger used despite context logger: %q", writeBuf.String())
+	}
+
+	if rfc9457.LoggerFrom(context.Background(), rfc9457.WriteComponent) != rfc9457.ComponentLogger(rfc9457.WriteComponent) {
+		t.Errorf("LoggerFrom without a context logger is not the component logger")
+	}
+}
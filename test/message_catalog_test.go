@@ -0,0 +1,120 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func newTestCatalog() *rfc9457.MessageCatalog {
+	c := rfc9457.NewMessageCatalog("en")
+	title := rfc9457.MessageKey{Type: rfc9457.NoResultsErrorType, MessageID: rfc9457.TitleMessageID}
+	detail := rfc9457.MessageKey{Type: rfc9457.NoResultsErrorType, MessageID: "user-not-found"}
+	c.AddMessage("en", detail, "No user with ID {id}")
+	c.AddMessage("de", title, "Keine Ergebnisse")
+	c.AddMessage("de", detail, "Kein Benutzer mit der ID {id}")
+	c.AddMessage("pt-PT", title, "Sem resultados")
+	c.SetFallbacks("pt-BR", "pt-PT")
+	return c
+}
+
+func TestMessageCatalog_Negotiate(t *testing.T) {
+	c := newTestCatalog()
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-CH", "de"},
+		{"fr, de;q=0.8", "de"},
+		{"de;q=0.5, en", "en"},
+		{"pt-BR", "pt-pt"},
+		{"fr", "en"},
+		{"de;q=0, fr", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			if got := c.Negotiate(tt.accept); got != tt.want {
+				t.Errorf("Negotiate(%q): got %q, want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMessage(t *testing.T) {
+	got := rfc9457.RenderMessage("Parameter '{name}' value {value} violates {constraint}", map[string]any{
+		"name":  "score",
+		"value": 150,
+	})
+	want := "Parameter 'score' value 150 violates {constraint}"
+	if got != want {
+		t.Errorf("RenderMessage: got %q, want %q", got, want)
+	}
+}
+
+func TestResponse_WriteLocalized(t *testing.T) {
+	rfc9457.SetMessageCatalog(newTestCatalog())
+	defer rfc9457.SetMessageCatalog(nil)
+
+	tests := []struct {
+		name       string
+		accept     string
+		wantLang   string
+		wantTitle  string
+		wantDetail string
+	}{
+		{"german", "de-DE,de;q=0.9", "de", "Keine Ergebnisse", "Kein Benutzer mit der ID 42"},
+		{"english", "en-US", "en", "No Results", "No user with ID 42"},
+		{"portuguese_fallback", "pt-BR", "pt-PT, en", "Sem resultados", "No user with ID 42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/users/42", nil)
+			req.Header.Set("Accept-Language", tt.accept)
+			resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+				Type:        rfc9457.NoResultsErrorType,
+				Title:       "No Results",
+				Status:      404,
+				Detail:      "No user with ID 42",
+				Instance:    req.URL.Path,
+				Request:     req,
+				MessageID:   "user-not-found",
+				MessageArgs: map[string]any{"id": 42},
+			})
+
+			rec := httptest.NewRecorder()
+			if err := resp.Write(rec); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if got := rec.Header().Get("Content-Language"); got != tt.wantLang {
+				t.Errorf("Content-Language: got %q, want %q", got, tt.wantLang)
+			}
+
+			var got rfc9457.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal response: %v", err)
+			}
+			if got.Title != tt.wantTitle {
+				t.Errorf("Title: got %q, want %q", got.Title, tt.wantTitle)
+			}
+			if got.Detail != tt.wantDetail {
+				t.Errorf("Detail: got %q, want %q", got.Detail, tt.wantDetail)
+			}
+
+			// Localizing never alters the response or the registered type
+			if resp.Title != "No Results" {
+				t.Errorf("Response Title mutated to %q", resp.Title)
+			}
+			def, _ := rfc9457.LookupErrorType(rfc9457.NoResultsErrorType)
+			if def.Title != "No Results" {
+				t.Errorf("Registered Title mutated to %q", def.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func TestExpvarObserver(t *testing.T) {
+	obs, err := rfc9457.NewExpvarObserver(rfc9457.ExpvarObserverArgs{
+		Name:           "rfc9457_test",
+		LatencyBuckets: []time.Duration{time.Millisecond, time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("NewExpvarObserver: %v", err)
+	}
+	rfc9457.AddObserver(obs)
+	defer rfc9457.ResetObservers()
+	rfc9457.SetScrubber(rfc9457.NewScrubber())
+	defer rfc9457.SetScrubber(nil)
+
+	if _, err := rfc9457.NewExpvarObserver(rfc9457.ExpvarObserverArgs{Name: "rfc9457_test"}); err == nil {
+		t.Error("NewExpvarObserver accepted a name that is already published")
+	}
+
+	for range 2 {
+		resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+			Type:   rfc9457.NoResultsErrorType,
+			Title:  "No Results",
+			Status: 404,
+			Detail: "No user jane@example.com",
+		})
+		if err := resp.Write(httptest.NewRecorder()); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	for _, body := range []string{
+		`{"type":"about:blank","title":"Not Found","status":404}`,
+		`{"type":"https://api.example.com/errors/quota","title":"Quota","status":429,"extensions":[5]}`,
+		`{"type":"https://api.example.com/errors/gone","title":"Gone","status":429}`,
+		`{"status":"404"}`,
+	} {
+		_, _ = rfc9457.ParseHTTPResponse(newHTTPResponse(404, "application/problem+json", body))
+	}
+	// Only problems received by clients are counted as decoded.
+	var decoded rfc9457.Response
+	if err := json.Unmarshal([]byte(`{"type":"about:blank","title":"Not Found","status":404}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	_ = json.Unmarshal([]byte(`{"status":"404"}`), &decoded)
+
+	counts := []struct {
+		event  string
+		typ    rfc9457.ErrorTypeURI
+		status int
+		want   int64
+	}{
+		{"constructed", rfc9457.NoResultsErrorType, 404, 2},
+		{"written", rfc9457.NoResultsErrorType, 404, 2},
+		{"decoded", rfc9457.AboutBlankErrorType, 404, 1},
+		{"decoded", "other", 429, 2},
+		{"decoded", "https://api.example.com/errors/quota", 429, 0},
+	}
+	for _, c := range counts {
+		if got := obs.Count(c.event, c.typ, c.status); got != c.want {
+			t.Errorf("Count(%s, %s, %d): got %d, want %d", c.event, c.typ, c.status, got, c.want)
+		}
+	}
+	if got := obs.DecodeFailures(); got != 2 {
+		t.Errorf("DecodeFailures: got %d, want 2", got)
+	}
+
+	var published map[string]any
+	if err := json.Unmarshal([]byte(expvar.Get("rfc9457_test").String()), &published); err != nil {
+		t.Fatalf("expvar value is not JSON: %v", err)
+	}
+	latency := published["write_latency"].(map[string]any)
+	if latency["count"] != float64(2) || latency["buckets"].(map[string]any)["3600"] != float64(2) {
+		t.Errorf("write_latency: got %v", latency)
+	}
+	scrubbed := published["scrubbed"].(map[string]any)["email"].(map[string]any)
+	if scrubbed[string(rfc9457.NoResultsErrorType)] != float64(2) {
+		t.Errorf("scrubbed: got %v", published["scrubbed"])
+	}
+
+	rec := httptest.NewRecorder()
+	obs.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`rfc9457_test_problems_written_total{type="` + string(rfc9457.NoResultsErrorType) + `",status="404"} 2`,
+		`rfc9457_test_problems_decoded_total{type="other",status="429"} 2`,
+		`rfc9457_test_problem_decode_failures_total 2`,
+		`rfc9457_test_problem_write_duration_seconds_bucket{le="+Inf"} 2`,
+		`rfc9457_test_problem_write_duration_seconds_count 2`,
+		`rfc9457_test_problem_scrubs_total{rule="email",type="` + string(rfc9457.NoResultsErrorType) + `"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Prometheus output lacks %q:\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func newOccurrenceRecord(id string) rfc9457.OccurrenceRecord {
+	resp := &rfc9457.Response{
+		Type:         rfc9457.NoResultsErrorType,
+		Title:        "No Results",
+		Status:       404,
+		Instance:     "urn:uuid:" + id,
+		OccurrenceID: id,
+	}
+	resp.AddInternalContext("sql", "SELECT * FROM users WHERE id = 42")
+	return rfc9457.NewOccurrenceRecord(resp)
+}
+
+func TestNewOccurrenceRecord_Snapshot(t *testing.T) {
+	resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:    rfc9457.QueryFailedErrorType,
+		Title:   "Query Failed",
+		Status:  500,
+		Detail:  "SELECT * FROM users",
+		Request: httptest.NewRequest("GET", "/api/users", nil),
+	})
+	resp.AddInternalContext("sql", "SELECT * FROM users")
+	rec := rfc9457.NewOccurrenceRecord(resp)
+
+	resp.Detail = "changed"
+	resp.AddInternalContext("sql", "changed")
+	resp.AddExtension(map[string]any{"added": true})
+	if rec.Response == resp {
+		t.Fatal("Record holds the live Response")
+	}
+	if rec.Response.Detail != "SELECT * FROM users" || len(rec.Response.Extensions) != 0 || rec.Context["sql"] != "SELECT * FROM users" {
+		t.Errorf("Record changed with the Response: %+v, context %v", rec.Response, rec.Context)
+	}
+}
+
+func TestNewOccurrenceHandler_NilStore(t *testing.T) {
+	if _, err := rfc9457.NewOccurrenceHandler(rfc9457.OccurrenceHandlerArgs{}); err == nil {
+		t.Error("NewOccurrenceHandler accepted a nil store")
+	}
+}
+
+func TestMemoryOccurrenceStore_RingBuffer(t *testing.T) {
+	ctx := context.Background()
+	store := rfc9457.NewMemoryOccurrenceStore(2)
+
+	for i := range 3 {
+		if err := store.SaveOccurrence(ctx, newOccurrenceRecord(fmt.Sprintf("id-%d", i))); err != nil {
+			t.Fatalf("SaveOccurrence: %v", err)
+		}
+	}
+
+	if _, err := store.LoadOccurrence(ctx, "id-0"); !errors.Is(err, rfc9457.ErrOccurrenceNotFound) {
+		t.Errorf("LoadOccurrence(id-0): got %v, want ErrOccurrenceNotFound", err)
+	}
+	for _, id := range []string{"id-1", "id-2"} {
+		rec, err := store.LoadOccurrence(ctx, id)
+		if err != nil {
+			t.Fatalf("LoadOccurrence(%s): %v", id, err)
+		}
+		if rec.ID != id {
+			t.Errorf("ID: got %q, want %q", rec.ID, id)
+		}
+	}
+}
+
+func TestFileOccurrenceStore_Roundtrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := rfc9457.NewFileOccurrenceStore(filepath.Join(t.TempDir(), "occurrences.jsonl"))
+	if err != nil {
+		t.Fatalf("NewFileOccurrenceStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	want := newOccurrenceRecord("abc")
+	if err := store.SaveOccurrence(ctx, newOccurrenceRecord("other")); err != nil {
+		t.Fatalf("SaveOccurrence: %v", err)
+	}
+	if err := store.SaveOccurrence(ctx, want); err != nil {
+		t.Fatalf("SaveOccurrence: %v", err)
+	}
+
+	got, err := store.LoadOccurrence(ctx, "abc")
+	if err != nil {
+		t.Fatalf("LoadOccurrence: %v", err)
+	}
+	assertRFC9457ErrorEqual(t, got.Response, want.Response)
+	if got.Context["sql"] != "SELECT * FROM users WHERE id = 42" {
+		t.Errorf("Context[sql]: got %v", got.Context["sql"])
+	}
+
+	if _, err := store.LoadOccurrence(ctx, "missing"); !errors.Is(err, rfc9457.ErrOccurrenceNotFound) {
+		t.Errorf("LoadOccurrence(missing): got %v, want ErrOccurrenceNotFound", err)
+	}
+}
+
+func TestOccurrenceHandler(t *testing.T) {
+	store := rfc9457.NewMemoryOccurrenceStore(10)
+	rfc9457.SetOccurrenceStore(store)
+	g, err := rfc9457.NewInstanceGenerator(rfc9457.InstanceArgs{
+		OccurrencesRootURI: "https://api.example.com/problems",
+	})
+	if err != nil {
+		t.Fatalf("NewInstanceGenerator: %v", err)
+	}
+	rfc9457.SetInstanceGenerator(g)
+	defer func() {
+		rfc9457.SetOccurrenceStore(nil)
+		rfc9457.SetInstanceGenerator(nil)
+	}()
+
+	// Writing a response with an occurrence records it in the store
+	resp := rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:    rfc9457.QueryFailedErrorType,
+		Title:   "Query Failed",
+		Status:  500,
+		Request: httptest.NewRequest("GET", "/api/users", nil),
+	})
+	if err := resp.Write(httptest.NewRecorder()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	handler, err := rfc9457.NewOccurrenceHandler(rfc9457.OccurrenceHandlerArgs{
+		Store: store,
+		Authorize: func(req *http.Request) bool {
+			return req.Header.Get("Authorization") == "Bearer support"
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewOccurrenceHandler: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		authorized bool
+		wantStatus int
+	}{
+		{"authorized", "GET", "/problems/" + resp.OccurrenceID, true, 200},
+		{"unauthorized", "GET", "/problems/" + resp.OccurrenceID, false, 401},
+		{"not_found", "GET", "/problems/missing", true, 404},
+		{"wrong_method", "DELETE", "/problems/" + resp.OccurrenceID, true, 405},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorized {
+				req.Header.Set("Authorization", "Bearer support")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Status code: got %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != 200 {
+				if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+					t.Errorf("Content-Type: got %q, want application/problem+json", ct)
+				}
+				return
+			}
+
+			var got rfc9457.OccurrenceRecord
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal record: %v", err)
+			}
+			if got.Instance != resp.Instance {
+				t.Errorf("Instance: got %q, want %q", got.Instance, resp.Instance)
+			}
+			if got.Context["method"] != "GET" {
+				t.Errorf("Context[method]: got %v, want GET", got.Context["method"])
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/rfc9457probe"
+)
+
+const probeRoutes = `
+# users API
+GET /users/{id:integer}
+GET /search?q=string!&limit=integer
+POST /users
+`
+
+// newConformingServer answers every malformed request with a problem.
+func newConformingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	writeProblem := func(w http.ResponseWriter, r *http.Request, typ rfc9457.ErrorTypeURI, title string, status int) {
+		err := rfc9457.NewResponse(rfc9457.ResponseArgs{Type: typ, Title: title, Status: status, Request: r}).Write(w)
+		if err != nil {
+			t.Errorf("Write: %v", err)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeProblem(w, r, rfc9457.MethodNotAllowedErrorType, "Method Not Allowed", 405)
+			return
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/users/")); err != nil {
+			writeProblem(w, r, rfc9457.InvalidParameterErrorType, "Invalid Parameter Type", 422)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodGet:
+			writeProblem(w, r, rfc9457.MethodNotAllowedErrorType, "Method Not Allowed", 405)
+		case r.URL.Query().Get("q") == "":
+			writeProblem(w, r, rfc9457.MissingParametersErrorType, "Missing Required Parameters", 400)
+		default:
+			if _, err := strconv.Atoi(r.URL.Query().Get("limit")); r.URL.Query().Has("limit") && err != nil {
+				writeProblem(w, r, rfc9457.InvalidParameterErrorType, "Invalid Parameter Type", 422)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeProblem(w, r, rfc9457.MethodNotAllowedErrorType, "Method Not Allowed", 405)
+			return
+		}
+		if r.ContentLength > 1024 {
+			writeProblem(w, r, rfc9457.AboutBlankErrorType, "Request Entity Too Large", 413)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProber_ConformingServer(t *testing.T) {
+	routes, err := rfc9457probe.ParseRoutes(strings.NewReader(probeRoutes))
+	if err != nil {
+		t.Fatalf("ParseRoutes: %v", err)
+	}
+	server := newConformingServer(t)
+	prober := &rfc9457probe.Prober{BaseURL: server.URL, OversizedBodySize: 64 << 10}
+	report, err := prober.Run(context.Background(), routes)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var kinds []rfc9457probe.CaseKind
+	for _, result := range report.Results {
+		kinds = append(kinds, result.Case.Kind)
+	}
+	want := []rfc9457probe.CaseKind{
+		rfc9457probe.BadType, rfc9457probe.WrongMethod,
+		rfc9457probe.MissingParam, rfc9457probe.BadType, rfc9457probe.WrongMethod,
+		rfc9457probe.WrongMethod, rfc9457probe.OversizedBody,
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("Case kinds: got %v, want %v", kinds, want)
+	}
+	if !report.Passed() {
+		var sb strings.Builder
+		_ = report.WriteText(&sb)
+		t.Errorf("Report failed:\n%s", sb.String())
+	}
+}
+
+func TestProber_NonConformingServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orders/abc" {
+			w.Header().Set("Content-Type", string(rfc9457.ApplicationProblemJSON))
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type":"https://example.com/unlisted","title":"Bad","status":422}`))
+			return
+		}
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	routes := []rfc9457probe.Route{{
+		Method: http.MethodGet,
+		Path:   "/orders/{id}",
+		Params: []rfc9457probe.Param{{Name: "id", In: rfc9457probe.InPath, Type: "integer", Required: true}},
+	}}
+	report, err := (&rfc9457probe.Prober{BaseURL: server.URL}).Run(context.Background(), routes)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	failures := report.Failures()
+	if len(failures) != 2 {
+		t.Fatalf("Failures: got %d, want 2", len(failures))
+	}
+
+	badType := failures[0]
+	if badType.Case.Kind != rfc9457probe.BadType || len(badType.Violations) != 2 {
+		t.Errorf("Bad type result: got %s with %q", badType.Case.Kind, badType.Violations)
+	}
+	wrongMethod := failures[1]
+	if len(wrongMethod.Violations) != 1 || !strings.Contains(wrongMethod.Violations[0], "Content-Type") {
+		t.Errorf("Wrong method violations: got %q", wrongMethod.Violations)
+	}
+
+	var sb strings.Builder
+	if err := report.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	if !strings.Contains(sb.String(), "2 cases, 2 failed") {
+		t.Errorf("Report summary:\n%s", sb.String())
+	}
+}
+
+func TestLoadOpenAPI(t *testing.T) {
+	doc := `{
+		"openapi": "3.1.0",
+		"paths": {
+			"/users/{id}": {
+				"parameters": [{"$ref": "#/components/parameters/UserID"}],
+				"get": {"parameters": [{"name": "fields", "in": "query", "schema": {"type": "string"}}]},
+				"put": {"requestBody": {"content": {"application/json": {}}}}
+			}
+		},
+		"components": {
+			"parameters": {
+				"UserID": {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}
+			}
+		}
+	}`
+	got, err := rfc9457probe.LoadOpenAPI([]byte(doc))
+	if err != nil {
+		t.Fatalf("LoadOpenAPI: %v", err)
+	}
+	id := rfc9457probe.Param{Name: "id", In: rfc9457probe.InPath, Type: "integer", Required: true}
+	want := []rfc9457probe.Route{
+		{Method: "GET", Path: "/users/{id}", Params: []rfc9457probe.Param{id, {Name: "fields", In: rfc9457probe.InQuery, Type: "string"}}},
+		{Method: "PUT", Path: "/users/{id}", Params: []rfc9457probe.Param{id}, HasBody: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadOpenAPI:\ngot  %+v\nwant %+v", got, want)
+	}
+}
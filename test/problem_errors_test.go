@@ -0,0 +1,90 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+var errNotFound = errors.New("not found")
+
+type validationError struct {
+	Detail string
+}
+
+func (e *validationError) Error() string {
+	return "validation: " + e.Detail
+}
+
+func TestProblemErrorRegistry_Map(t *testing.T) {
+	reg := rfc9457.NewProblemErrorRegistry()
+	reg.Register(rfc9457.NoResultsErrorType, func(*rfc9457.Response) error {
+		return errNotFound
+	})
+	reg.Register(rfc9457.ErrorTypeRootURI+rfc9457.TestServerAPIPath+"/validation/", func(p *rfc9457.Response) error {
+		return &validationError{Detail: p.Detail}
+	})
+	reg.Register(rfc9457.ErrorTypeRootURI+rfc9457.TestServerAPIPath+"/validation/unauthorized", func(*rfc9457.Response) error {
+		return nil
+	})
+
+	t.Run("exact_match_sentinel", func(t *testing.T) {
+		problem := &rfc9457.Response{Type: rfc9457.NoResultsErrorType, Status: 404}
+		err := reg.Map(problem)
+		if !errors.Is(err, errNotFound) {
+			t.Errorf("errors.Is(ErrNotFound): got false for %v", err)
+		}
+		var got *rfc9457.Response
+		if !errors.As(err, &got) || got != problem {
+			t.Errorf("errors.As(*Response): got %v, want original problem", got)
+		}
+	})
+
+	t.Run("prefix_match_custom_type", func(t *testing.T) {
+		problem := &rfc9457.Response{Type: rfc9457.ConstraintViolationErrorType, Status: 422, Detail: "score > 100"}
+		err := fmt.Errorf("calling API: %w", reg.Map(problem))
+		var verr *validationError
+		if !errors.As(err, &verr) || verr.Detail != "score > 100" {
+			t.Errorf("errors.As(*validationError): got %v", err)
+		}
+		var got *rfc9457.Response
+		if !errors.As(err, &got) {
+			t.Errorf("errors.As(*Response): not found in %v", err)
+		}
+	})
+
+	t.Run("longest_prefix_wins_nil_leaves_unmapped", func(t *testing.T) {
+		problem := &rfc9457.Response{Type: rfc9457.UnauthorizedErrorType, Status: 401}
+		if err := reg.Map(problem); err != error(problem) {
+			t.Errorf("Map: got %v, want problem itself", err)
+		}
+	})
+
+	t.Run("no_match", func(t *testing.T) {
+		problem := &rfc9457.Response{Type: "https://example.com/other", Status: 400}
+		if err := reg.Map(problem); err != error(problem) {
+			t.Errorf("Map: got %v, want problem itself", err)
+		}
+	})
+}
+
+func TestTransport_MapsProblemErrors(t *testing.T) {
+	server := newProblemServer(t)
+	reg := rfc9457.NewProblemErrorRegistry()
+	reg.Register(rfc9457.NoResultsErrorType, func(*rfc9457.Response) error {
+		return errNotFound
+	})
+	client := &http.Client{Transport: &rfc9457.Transport{Errors: reg}}
+
+	_, err := client.Get(server.URL + "/missing")
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("errors.Is(ErrNotFound): got false for %v", err)
+	}
+	var problem *rfc9457.Response
+	if !errors.As(err, &problem) || problem.Status != 404 {
+		t.Errorf("errors.As(*Response): got %v", problem)
+	}
+}
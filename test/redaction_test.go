@@ -0,0 +1,122 @@
+package test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/rfc9457test"
+)
+
+type debugInfo struct {
+	Query string `json:"query"`
+}
+
+func TestResponse_Redact(t *testing.T) {
+	newProblem := func() *rfc9457.Response {
+		return &rfc9457.Response{
+			Type:   rfc9457.QueryFailedErrorType,
+			Title:  "Query Failed",
+			Status: 500,
+			Detail: "pq: relation \"users\" does not exist",
+			Extensions: []rfc9457.Extension{
+				map[string]any{"trace_id": "abc"},
+				rfc9457.MarkInternal(debugInfo{Query: "SELECT * FROM users"}),
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		profile    rfc9457.RedactionProfile
+		problem    *rfc9457.Response
+		want       *rfc9457.Response
+		extensions int
+	}{
+		{
+			name:       "debug_unchanged",
+			profile:    rfc9457.DebugProfile,
+			problem:    newProblem(),
+			want:       newProblem(),
+			extensions: 2,
+		},
+		{
+			name:       "staging_drops_internal_extensions",
+			profile:    rfc9457.StagingProfile,
+			problem:    newProblem(),
+			want:       &rfc9457.Response{Type: rfc9457.QueryFailedErrorType, Title: "Query Failed", Status: 500, Detail: "pq: relation \"users\" does not exist"},
+			extensions: 1,
+		},
+		{
+			name:       "production_generic_5xx",
+			profile:    rfc9457.ProductionProfile,
+			problem:    newProblem(),
+			want:       &rfc9457.Response{Type: rfc9457.QueryFailedErrorType, Title: "Internal Server Error", Status: 500},
+			extensions: 1,
+		},
+		{
+			name:    "production_sensitive_4xx",
+			profile: rfc9457.ProductionProfile,
+			problem: &rfc9457.Response{Type: rfc9457.InvalidDBQueryErrorType, Title: "Invalid Database Query", Status: 400, Detail: "syntax error at or near \"FROM\""},
+			want:    &rfc9457.Response{Type: rfc9457.InvalidDBQueryErrorType, Title: "Invalid Database Query", Status: 400, Detail: rfc9457.WithheldDetail},
+		},
+		{
+			name:    "production_plain_4xx_unchanged",
+			profile: rfc9457.ProductionProfile,
+			problem: &rfc9457.Response{Type: rfc9457.NoResultsErrorType, Title: "No Results", Status: 404, Detail: "No user with ID 42"},
+			want:    &rfc9457.Response{Type: rfc9457.NoResultsErrorType, Title: "No Results", Status: 404, Detail: "No user with ID 42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.problem.Detail
+			got, _ := tt.problem.Redact(tt.profile)
+			assertRFC9457ErrorEqual(t, got, tt.want)
+			if len(got.Extensions) != tt.extensions {
+				t.Errorf("Extensions: got %d, want %d", len(got.Extensions), tt.extensions)
+			}
+			if tt.problem.Detail != original {
+				t.Errorf("Redact modified the original Detail")
+			}
+		})
+	}
+}
+
+func TestResponse_WriteAppliesRedactionProfile(t *testing.T) {
+	rfc9457.SetRedactionProfile(rfc9457.ProductionProfile)
+	defer rfc9457.SetRedactionProfile(rfc9457.DebugProfile)
+
+	resp := &rfc9457.Response{
+		Type:       rfc9457.QueryFailedErrorType,
+		Title:      "Query Failed",
+		Status:     500,
+		Detail:     "pq: relation \"users\" does not exist",
+		Extensions: []rfc9457.Extension{rfc9457.MarkInternal(debugInfo{Query: "SELECT 1"})},
+	}
+	rec := httptest.NewRecorder()
+	if err := resp.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	rfc9457test.AssertProblem(t, rec, &rfc9457.Response{
+		Type:   rfc9457.QueryFailedErrorType,
+		Title:  "Internal Server Error",
+		Status: 500,
+	})
+}
+
+func TestParseRedactionProfile(t *testing.T) {
+	for _, name := range []string{"debug", "Staging", " production "} {
+		p, err := rfc9457.ParseRedactionProfile(name)
+		if err != nil {
+			t.Errorf("ParseRedactionProfile(%q): %v", name, err)
+			continue
+		}
+		if _, err := rfc9457.ParseRedactionProfile(p.String()); err != nil {
+			t.Errorf("ParseRedactionProfile(%q) did not round-trip", p)
+		}
+	}
+	if _, err := rfc9457.ParseRedactionProfile("prod"); err == nil {
+		t.Errorf("ParseRedactionProfile accepted an unknown profile")
+	}
+}
@@ -1,9 +1,11 @@
 package test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/mikeschinkel/go-rfc9457"
 )
@@ -213,3 +215,26 @@ func TestNewRFC9457Error(t *testing.T) {
 
 	assertRFC9457ErrorEqual(t, got, want)
 }
+
+func TestResponse_WriteEncodeError(t *testing.T) {
+	var written int
+	rfc9457.AddObserver(rfc9457.ProblemWrittenFunc(func(context.Context, *rfc9457.Response, int, time.Duration) {
+		written++
+	}))
+	defer rfc9457.ResetObservers()
+	store := rfc9457.NewMemoryOccurrenceStore(10)
+	rfc9457.SetOccurrenceStore(store)
+	defer rfc9457.SetOccurrenceStore(nil)
+
+	resp := &rfc9457.Response{Type: rfc9457.NoResultsErrorType, Title: "No Results", Status: 404, OccurrenceID: "unsent"}
+	resp.AddExtension(make(chan int))
+	if err := resp.Write(httptest.NewRecorder()); err == nil {
+		t.Fatal("Write: got nil, want an encoding error")
+	}
+	if written != 0 {
+		t.Errorf("Observers were notified %d times for a problem that was not written", written)
+	}
+	if _, err := store.LoadOccurrence(context.Background(), "unsent"); err == nil {
+		t.Error("Occurrence of a problem that was not written was recorded")
+	}
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// fakeClock records requested sleeps instead of sleeping.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func TestIsRetryable(t *testing.T) {
+	rfc9457.RegisterErrorType(rfc9457.ErrorTypeDefinition{
+		Type:   "https://example.com/errors/maintenance",
+		Title:  "Maintenance",
+		Status: 500,
+		Retry:  rfc9457.NeverRetry,
+	})
+
+	tests := []struct {
+		name    string
+		problem *rfc9457.Response
+		want    bool
+	}{
+		{"status_429", &rfc9457.Response{Status: 429}, true},
+		{"status_503", &rfc9457.Response{Status: 503}, true},
+		{"status_501", &rfc9457.Response{Status: 501}, false},
+		{"status_404", &rfc9457.Response{Status: 404}, false},
+		{"registry_never", &rfc9457.Response{Type: "https://example.com/errors/maintenance", Status: 503}, false},
+		{
+			name: "extension_overrides_status",
+			problem: &rfc9457.Response{Status: 409, Extensions: []rfc9457.Extension{
+				rfc9457.RetryableExtension{Retryable: true},
+			}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rfc9457.IsRetryable(tt.problem); got != tt.want {
+				t.Errorf("IsRetryable: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+		{"-5", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := rfc9457.ParseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseRetryAfter(%q): got %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := rfc9457.RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+		Rand:      func() float64 { return 0.5 },
+	}
+	problem := &rfc9457.Response{Status: 503}
+
+	for attempt, want := range map[int]time.Duration{
+		1: 75 * time.Millisecond,
+		2: 150 * time.Millisecond,
+		3: 300 * time.Millisecond,
+		5: 750 * time.Millisecond,
+	} {
+		got, ok := policy.Delay(attempt, problem)
+		if !ok || got != want {
+			t.Errorf("Delay(%d): got %v, %v, want %v, true", attempt, got, ok, want)
+		}
+	}
+
+	problem.Extensions = []rfc9457.Extension{rfc9457.RateLimitExtension{
+		RateLimit: rfc9457.RateLimit{Limit: 100, Remaining: 0, Reset: 2},
+	}}
+	if got, ok := policy.Delay(1, problem); ok {
+		t.Errorf("Delay beyond MaxDelay: got %v, want ok=false", got)
+	}
+}
+
+func TestRetryClient(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			_ = (&rfc9457.Response{Title: "Service Unavailable", Status: 503}).Write(w)
+		case 2:
+			_ = (&rfc9457.Response{Title: "Bad Gateway", Status: 502}).Write(w)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		name   string
+		client *http.Client
+	}{
+		{"plain_client", &http.Client{}},
+		{"problem_transport", &http.Client{Transport: rfc9457.NewTransport(nil)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			clock := &fakeClock{now: time.Now()}
+			rc := &rfc9457.RetryClient{
+				Client: tt.client,
+				Policy: rfc9457.RetryPolicy{
+					BaseDelay: time.Second,
+					Clock:     clock,
+					Rand:      func() float64 { return 0 },
+				},
+			}
+
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := rc.Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			if resp.StatusCode != 200 {
+				t.Errorf("Status code: got %d, want 200", resp.StatusCode)
+			}
+			want := []time.Duration{2 * time.Second, time.Second}
+			if len(clock.sleeps) != 2 || clock.sleeps[0] != want[0] || clock.sleeps[1] != want[1] {
+				t.Errorf("Sleeps: got %v, want %v", clock.sleeps, want)
+			}
+		})
+	}
+}
+
+func TestRetryClient_GivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = (&rfc9457.Response{Title: "Service Unavailable", Status: 503}).Write(w)
+	}))
+	defer server.Close()
+
+	rc := &rfc9457.RetryClient{
+		Client: &http.Client{Transport: rfc9457.NewTransport(nil)},
+		Policy: rfc9457.RetryPolicy{MaxAttempts: 3, Clock: &fakeClock{}},
+	}
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	_, err := rc.Do(req)
+
+	var problem *rfc9457.Response
+	if !errors.As(err, &problem) || problem.Status != 503 {
+		t.Errorf("Do: got %v, want 503 problem", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Calls: got %d, want 3", calls.Load())
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+	"github.com/mikeschinkel/go-rfc9457/rfc9457test"
+)
+
+// recordingTB captures failures so the assertions themselves can be tested.
+type recordingTB struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func writeProblem(t *testing.T, resp *rfc9457.Response) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := resp.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return rec
+}
+
+func TestAssertProblem(t *testing.T) {
+	problem := &rfc9457.Response{
+		Type:       rfc9457.ConstraintViolationErrorType,
+		Title:      "Constraint Violation",
+		Status:     422,
+		Detail:     "Parameter 'score' value 150 violates constraint range[0..100]",
+		Instance:   "/api/users/by-score/150",
+		Extensions: []rfc9457.Extension{rfc9457.RetryableExtension{Retryable: false}},
+	}
+	rec := writeProblem(t, problem)
+
+	// Passes against itself, including struct vs decoded-map extensions
+	rfc9457test.AssertProblem(t, rec, problem)
+	rfc9457test.AssertMatches(t, rec,
+		rfc9457test.HasType(rfc9457.ConstraintViolationErrorType),
+		rfc9457test.HasStatus(422),
+		rfc9457test.DetailContains("range[0..100]"),
+		rfc9457test.HasExtensionValue("retryable", false),
+	)
+
+	// Reports every mismatch
+	tb := &recordingTB{}
+	want := *problem
+	want.Status = 400
+	want.Detail = "other"
+	rfc9457test.AssertProblem(tb, rec, &want)
+	if len(tb.errors) != 3 {
+		t.Errorf("AssertProblem errors: got %d, want 3: %q", len(tb.errors), tb.errors)
+	}
+
+	tb = &recordingTB{}
+	rfc9457test.AssertMatches(tb, rec,
+		rfc9457test.HasTitle("Nope"),
+		rfc9457test.HasExtensionMember("missing"),
+	)
+	if len(tb.errors) != 2 {
+		t.Errorf("AssertMatches errors: got %d, want 2: %q", len(tb.errors), tb.errors)
+	}
+}
+
+func TestDecodeProblem_Failures(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantError   string
+	}{
+		{"wrong_content_type", "text/html", `{"status":500}`, "Content-Type"},
+		{"empty_body", "application/problem+json", "", "empty"},
+		{"invalid_json", "application/problem+json", "<html>oops</html>", "<html>oops</html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", tt.contentType)
+			rec.WriteHeader(500)
+			_, _ = rec.WriteString(tt.body)
+
+			tb := &recordingTB{}
+			rfc9457test.DecodeProblem(tb, rec)
+			if !strings.Contains(strings.Join(tb.errors, "\n"), tt.wantError) {
+				t.Errorf("DecodeProblem errors: got %q, want mention of %q", tb.errors, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := &rfc9457.Response{Type: rfc9457.NoResultsErrorType, Status: 404}
+	want := &rfc9457.Response{Type: rfc9457.NoResultsErrorType, Status: 404, Extensions: []rfc9457.Extension{
+		map[string]any{"id": 42},
+	}}
+
+	diffs := rfc9457test.Diff(got, want)
+	if len(diffs) != 1 || diffs[0].Field != "Extensions[0]" || diffs[0].Got != "null" || diffs[0].Want != `{"id":42}` {
+		t.Errorf("Diff: got %v", diffs)
+	}
+}
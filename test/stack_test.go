@@ -0,0 +1,108 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func newProblemDeepInService() *rfc9457.Response {
+	return rfc9457.NewResponse(rfc9457.ResponseArgs{
+		Type:   rfc9457.QueryFailedErrorType,
+		Title:  "Query Failed",
+		Status: 500,
+	})
+}
+
+func TestCallerCapture(t *testing.T) {
+	rfc9457.SetCallerCapture(rfc9457.NewCallerCapture(rfc9457.CallerCaptureArgs{Depth: 2}))
+	defer rfc9457.SetCallerCapture(nil)
+
+	resp := newProblemDeepInService()
+	stack := resp.Stack()
+	if len(stack) != 2 {
+		t.Fatalf("Stack: got %d frames, want 2: %v", len(stack), stack)
+	}
+	if !strings.HasSuffix(stack[0].Function, ".newProblemDeepInService") || !strings.HasSuffix(stack[0].File, "stack_test.go") {
+		t.Errorf("First frame: got %s", stack[0])
+	}
+	if !strings.HasSuffix(stack[1].Function, ".TestCallerCapture") {
+		t.Errorf("Second frame: got %s", stack[1])
+	}
+
+	if got := fmt.Sprintf("%+v", resp); !strings.Contains(got, "newProblemDeepInService") {
+		t.Errorf("%%+v does not include the stack:\n%s", got)
+	}
+	if got := fmt.Sprintf("%v", resp); strings.Contains(got, "newProblemDeepInService") {
+		t.Errorf("%%v includes the stack:\n%s", got)
+	}
+
+	rfc9457.SetCallerCapture(rfc9457.NewCallerCapture(rfc9457.CallerCaptureArgs{
+		Keep: func(f rfc9457.StackFrame) bool {
+			return !strings.HasSuffix(f.Function, ".newProblemDeepInService")
+		},
+	}))
+	if stack := newProblemDeepInService().Stack(); len(stack) == 0 || !strings.HasSuffix(stack[0].Function, ".TestCallerCapture") {
+		t.Errorf("Filtered stack: got %v", stack)
+	}
+
+	rfc9457.SetCallerCapture(nil)
+	if stack := newProblemDeepInService().Stack(); stack != nil {
+		t.Errorf("Stack with capture disabled: got %v", stack)
+	}
+}
+
+func TestResponse_WriteDebugExtension(t *testing.T) {
+	rfc9457.SetCallerCapture(rfc9457.NewCallerCapture(rfc9457.CallerCaptureArgs{}))
+	defer rfc9457.SetCallerCapture(nil)
+	resp := newProblemDeepInService()
+
+	rec := httptest.NewRecorder()
+	if err := resp.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var got rfc9457.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(got.Extensions) != 1 {
+		t.Fatalf("Extensions: got %d, want 1", len(got.Extensions))
+	}
+	debug, ok := got.Extensions[0].(rfc9457.DebugExtension)
+	if !ok || len(debug.Stack) == 0 || !strings.HasSuffix(debug.Stack[0].Function, ".newProblemDeepInService") {
+		t.Errorf("Debug extension: got %#v", got.Extensions[0])
+	}
+	if len(resp.Extensions) != 0 {
+		t.Errorf("Write added the debug extension to the original")
+	}
+
+	rfc9457.SetRedactionProfile(rfc9457.StagingProfile)
+	defer rfc9457.SetRedactionProfile(rfc9457.DebugProfile)
+	rec = httptest.NewRecorder()
+	if err := resp.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if strings.Contains(rec.Body.String(), "stack") {
+		t.Errorf("Staging body includes the stack: %s", rec.Body.String())
+	}
+}
+
+func TestUnmarshalJSON_RegisteredExtensionMatching(t *testing.T) {
+	var got rfc9457.Response
+	data := `{"type":"about:blank","title":"Bad","status":400,"extensions":[{"stack":[],"added_later":1},{"balance":30},{}]}`
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if _, ok := got.Extensions[0].(rfc9457.DebugExtension); !ok {
+		t.Errorf("Extension 0: got %T, want DebugExtension", got.Extensions[0])
+	}
+	for _, i := range []int{1, 2} {
+		if _, ok := got.Extensions[i].(map[string]any); !ok {
+			t.Errorf("Extension %d: got %T, want map[string]any", i, got.Extensions[i])
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+// lineWriter sends each write, one log line, to a channel.
+type lineWriter chan string
+
+func (w lineWriter) Write(b []byte) (int, error) {
+	w <- string(b)
+	return len(b), nil
+}
+
+func TestTimeoutHandler_Fast(t *testing.T) {
+	h := rfc9457.NewTimeoutHandler(rfc9457.TimeoutHandlerArgs{
+		Timeout: time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Test", "yes")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, "created")
+		}),
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/items", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("X-Test") != "yes" {
+		t.Errorf("Response: got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimeoutHandler_TimedOut(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	h := rfc9457.NewTimeoutHandler(rfc9457.TimeoutHandlerArgs{
+		Timeout:    10 * time.Millisecond,
+		Status:     http.StatusGatewayTimeout,
+		RetryAfter: 1500 * time.Millisecond,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			<-req.Context().Done()
+			time.Sleep(10 * time.Millisecond)
+			_, err := io.WriteString(w, "too late")
+			lateWrite <- err
+		}),
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/slow", nil))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("Status: got %d, want 504", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After: got %q, want %q", got, "2")
+	}
+	var resp rfc9457.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, rec.Body.String())
+	}
+	if resp.Type != rfc9457.TimeoutErrorType || resp.Title != "Gateway Timeout" {
+		t.Errorf("Problem: got %+v", resp)
+	}
+	if retryable, _ := resp.ExtensionMember("retryable"); retryable != true {
+		t.Errorf("retryable: got %v, want true", retryable)
+	}
+	if err := <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("Late write: got %v, want http.ErrHandlerTimeout", err)
+	}
+	if strings.Contains(rec.Body.String(), "too late") {
+		t.Errorf("Late write reached the client: %s", rec.Body.String())
+	}
+}
+
+func TestTimeoutHandler_Canceled(t *testing.T) {
+	h := rfc9457.NewTimeoutHandler(rfc9457.TimeoutHandlerArgs{
+		Timeout: time.Hour,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			<-req.Context().Done()
+		}),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/slow", nil).WithContext(ctx))
+
+	var resp rfc9457.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, rec.Body.String())
+	}
+	if resp.Detail != "The request was canceled before it completed" {
+		t.Errorf("Detail: got %q", resp.Detail)
+	}
+}
+
+func TestTimeoutHandler_Panics(t *testing.T) {
+	lines := make(lineWriter, 10)
+	rfc9457.SetComponentLogger(rfc9457.HandlerComponent, slog.New(slog.NewJSONHandler(lines, nil)))
+	defer rfc9457.SetComponentLogger(rfc9457.HandlerComponent, nil)
+
+	panicking := func(after time.Duration) http.Handler {
+		return rfc9457.NewTimeoutHandler(rfc9457.TimeoutHandlerArgs{
+			Timeout: 10 * time.Millisecond,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				time.Sleep(after)
+				panic("boom")
+			}),
+		})
+	}
+
+	t.Run("before_timeout", func(t *testing.T) {
+		defer func() {
+			p, _ := recover().(string)
+			if !strings.HasPrefix(p, "boom\n\ngoroutine stack:\n") || !strings.Contains(p, "timeout_handler_test.go") {
+				t.Errorf("recover: got %q, want the handler's panic with its stack", p)
+			}
+		}()
+		panicking(0).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		t.Error("ServeHTTP returned without panicking")
+	})
+
+	t.Run("after_timeout", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		panicking(30*time.Millisecond).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Status: got %d, want 503", rec.Code)
+		}
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, "Handler panicked after timeout") || !strings.Contains(line, `"panic":"boom"`) {
+				t.Errorf("Log: got %s", line)
+			}
+		case <-time.After(time.Second):
+			t.Error("Panic after timeout was not logged")
+		}
+	})
+}
+
+// TestTimeoutHandler_PanicAtDeadline checks that a panic racing the timeout
+// is either propagated or logged, never lost.
+func TestTimeoutHandler_PanicAtDeadline(t *testing.T) {
+	const iterations = 200
+	lines := make(lineWriter, iterations)
+	rfc9457.SetComponentLogger(rfc9457.HandlerComponent, slog.New(slog.NewJSONHandler(lines, nil)))
+	defer rfc9457.SetComponentLogger(rfc9457.HandlerComponent, nil)
+
+	h := rfc9457.NewTimeoutHandler(rfc9457.TimeoutHandlerArgs{
+		Timeout: time.Millisecond,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			<-req.Context().Done()
+			panic("boom")
+		}),
+	})
+	serve := func() (propagated bool) {
+		defer func() {
+			propagated = recover() != nil
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		return false
+	}
+	var logged int
+	for range iterations {
+		if serve() {
+			continue
+		}
+		select {
+		case <-lines:
+			logged++
+		case <-time.After(time.Second):
+			t.Fatal("Panic at the deadline was neither propagated nor logged")
+		}
+	}
+	t.Logf("%d of %d panics logged after the timeout", logged, iterations)
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+var (
+	noResultsTitle  = rfc9457.MessageKey{Type: rfc9457.NoResultsErrorType, MessageID: rfc9457.TitleMessageID}
+	noResultsDetail = rfc9457.MessageKey{Type: rfc9457.NoResultsErrorType, MessageID: "user-not-found"}
+)
+
+func TestTranslationFile_Roundtrip(t *testing.T) {
+	want := rfc9457.TranslationFile{
+		Language: "de",
+		Units: []rfc9457.TranslationUnit{
+			{Key: noResultsTitle, Source: "No Results", Target: "Keine Ergebnisse"},
+			{Key: noResultsDetail, Source: "No user with ID {id}\nTry again", Target: "Kein \"Benutzer\" mit der ID {id}", Fuzzy: true},
+		},
+	}
+
+	for _, format := range []rfc9457.TranslationFormat{rfc9457.POFormat, rfc9457.XLIFFFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := rfc9457.WriteTranslationFile(&buf, want, format); err != nil {
+				t.Fatalf("WriteTranslationFile: %v", err)
+			}
+			got, err := rfc9457.ReadTranslationFile(&buf, format)
+			if err != nil {
+				t.Fatalf("ReadTranslationFile: %v\n%s", err, buf.String())
+			}
+			if got.Language != want.Language {
+				t.Errorf("Language: got %q, want %q", got.Language, want.Language)
+			}
+			if len(got.Units) != len(want.Units) {
+				t.Fatalf("Units: got %d, want %d", len(got.Units), len(want.Units))
+			}
+			for i := range want.Units {
+				if got.Units[i] != want.Units[i] {
+					t.Errorf("Unit %d: got %+v, want %+v", i, got.Units[i], want.Units[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCatalogFS(t *testing.T) {
+	var po, xlf bytes.Buffer
+	_ = rfc9457.WritePO(&po, rfc9457.TranslationFile{
+		// No Language header: taken from the file name
+		Units: []rfc9457.TranslationUnit{
+			{Key: noResultsTitle, Source: "No Results", Target: "Keine Ergebnisse"},
+			{Key: noResultsDetail, Source: "No user with ID {id}", Target: "Veraltet", Fuzzy: true},
+		},
+	})
+	_ = rfc9457.WriteXLIFF(&xlf, rfc9457.TranslationFile{
+		Language: "fr",
+		Units: []rfc9457.TranslationUnit{
+			{Key: noResultsTitle, Source: "No Results", Target: "Aucun résultat"},
+		},
+	})
+	fsys := fstest.MapFS{
+		"locales/de.po":  {Data: po.Bytes()},
+		"locales/fr.xlf": {Data: xlf.Bytes()},
+	}
+
+	c := rfc9457.NewMessageCatalog("en")
+	if err := rfc9457.LoadCatalogFS(c, fsys, "locales/*.po", "locales/*.xlf"); err != nil {
+		t.Fatalf("LoadCatalogFS: %v", err)
+	}
+
+	tests := []struct {
+		lang   string
+		key    rfc9457.MessageKey
+		want   string
+		wantOK bool
+	}{
+		{"de", noResultsTitle, "Keine Ergebnisse", true},
+		{"fr", noResultsTitle, "Aucun résultat", true},
+		{"de", noResultsDetail, "", false},
+	}
+	for _, tt := range tests {
+		got, _, ok := c.Message(tt.lang, tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Message(%s, %s): got %q, %v, want %q, %v", tt.lang, tt.key.MessageID, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLintTranslations(t *testing.T) {
+	stale := rfc9457.MessageKey{Type: rfc9457.NoResultsErrorType, MessageID: "removed"}
+	constraint := rfc9457.MessageKey{Type: rfc9457.ConstraintViolationErrorType, MessageID: rfc9457.TitleMessageID}
+
+	template := rfc9457.TranslationFile{Units: []rfc9457.TranslationUnit{
+		{Key: constraint, Source: "Constraint Violation"},
+		{Key: noResultsTitle, Source: "No Results"},
+		{Key: noResultsDetail, Source: "No user with ID {id}"},
+	}}
+	translation := rfc9457.TranslationFile{Language: "de", Units: []rfc9457.TranslationUnit{
+		{Key: noResultsTitle, Source: "No Result", Target: "Keine Ergebnisse"},
+		{Key: noResultsDetail, Source: "No user with ID {id}"},
+		{Key: stale, Source: "Gone", Target: "Weg"},
+	}}
+
+	got := rfc9457.LintTranslations(template, translation)
+	want := []struct {
+		kind rfc9457.TranslationIssueKind
+		key  rfc9457.MessageKey
+	}{
+		{rfc9457.StaleTranslation, stale},
+		{rfc9457.StaleTranslation, noResultsTitle},
+		{rfc9457.MissingTranslation, noResultsDetail},
+		{rfc9457.MissingTranslation, constraint},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("LintTranslations: got %d issues, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Kind != want[i].kind || got[i].Key != want[i].key {
+			t.Errorf("Issue %d: got %s, want %s %s|%s", i, got[i], want[i].kind, want[i].key.Type, want[i].key.MessageID)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func newProblemServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		_ = rfc9457.NewResponse(rfc9457.ResponseArgs{
+			Type:     rfc9457.NoResultsErrorType,
+			Title:    "No Results",
+			Status:   404,
+			Instance: r.URL.Path,
+		}).Write(w)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTransport(t *testing.T) {
+	server := newProblemServer(t)
+	client := &http.Client{Transport: rfc9457.NewTransport(nil)}
+
+	t.Run("success_passes_through", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/redirect")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != 200 {
+			t.Errorf("Status code: got %d, want 200", resp.StatusCode)
+		}
+	})
+
+	t.Run("problem_becomes_error", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/missing")
+		if resp != nil {
+			t.Errorf("Response: got %v, want nil", resp.Status)
+		}
+		var problem *rfc9457.Response
+		if !errors.As(err, &problem) {
+			t.Fatalf("Get: got %v, want *rfc9457.Response", err)
+		}
+		if problem.Type != rfc9457.NoResultsErrorType {
+			t.Errorf("Type: got %v, want %v", problem.Type, rfc9457.NoResultsErrorType)
+		}
+		if problem.HTTPResponse() == nil || problem.HTTPResponse().StatusCode != 404 {
+			t.Errorf("HTTPResponse: got %v, want original 404 response", problem.HTTPResponse())
+		}
+	})
+
+	t.Run("opt_out_per_request", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/missing", nil)
+		req = req.WithContext(rfc9457.WithoutProblemErrors(req.Context()))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != 404 {
+			t.Errorf("Status code: got %d, want 404", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		members []string
+	}{
+		{
+			name: "valid_registered_type",
+			doc:  `{"type":"https://schema.xmlui.org/errors/test-server/api/database/no-results","title":"No Results","status":404}`,
+		},
+		{
+			name: "empty_document",
+			doc:  `{}`,
+		},
+		{
+			name:    "status_mismatch_with_registry",
+			doc:     `{"type":"https://schema.xmlui.org/errors/test-server/api/database/no-results","title":"No Results","status":500}`,
+			members: []string{"status"},
+		},
+		{
+			name:    "wrong_member_types",
+			doc:     `{"type":42,"title":"Conflict","status":"409","extensions":{}}`,
+			members: []string{"type", "status", "extensions"},
+		},
+		{
+			name:    "success_status",
+			doc:     `{"title":"OK","status":200}`,
+			members: []string{"status"},
+		},
+		{
+			name:    "about_blank_title",
+			doc:     `{"type":"about:blank","title":"Oops","status":404}`,
+			members: []string{"title"},
+		},
+		{
+			name: "top_level_extension_member",
+			doc:  `{"title":"Conflict","status":409,"balance":30}`,
+		},
+		{
+			name:    "invalid_instance",
+			doc:     `{"title":"Conflict","status":409,"instance":"%zz"}`,
+			members: []string{"instance"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, violations, err := rfc9457.ValidateJSON([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("ValidateJSON: %v", err)
+			}
+			if got == nil {
+				t.Fatalf("ValidateJSON returned no problem")
+			}
+			var members []string
+			for _, v := range violations {
+				members = append(members, v.Member)
+			}
+			if len(members) != len(tt.members) {
+				t.Fatalf("Violations: got %v, want members %v", violations, tt.members)
+			}
+			for i := range members {
+				if members[i] != tt.members[i] {
+					t.Errorf("Violation %d: got %q, want %q", i, members[i], tt.members[i])
+				}
+			}
+		})
+	}
+
+	if _, _, err := rfc9457.ValidateJSON([]byte(`[]`)); err == nil {
+		t.Errorf("ValidateJSON accepted a non-object document")
+	}
+}
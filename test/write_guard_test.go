@@ -0,0 +1,93 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mikeschinkel/go-rfc9457"
+)
+
+func TestResponse_WriteNormalizesInvalidStatus(t *testing.T) {
+	for _, status := range []int{0, -1, 200, 302, 999} {
+		t.Run(fmt.Sprintf("status_%d", status), func(t *testing.T) {
+			resp := &rfc9457.Response{
+				Type:   rfc9457.InternalServerErrorType,
+				Title:  "Internal Server Error",
+				Status: status,
+			}
+			rec := httptest.NewRecorder()
+			if err := resp.Write(rec); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if rec.Code != 500 {
+				t.Errorf("Status code: got %d, want 500", rec.Code)
+			}
+
+			var got rfc9457.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal response: %v", err)
+			}
+			if got.Status != 500 {
+				t.Errorf("Response Status: got %d, want 500", got.Status)
+			}
+			if resp.Status != status {
+				t.Errorf("Original Status mutated to %d", resp.Status)
+			}
+		})
+	}
+}
+
+func TestResponse_WriteRejectsInvalidStatus(t *testing.T) {
+	rfc9457.SetStatusPolicy(rfc9457.RejectInvalidStatus)
+	defer rfc9457.SetStatusPolicy(rfc9457.NormalizeInvalidStatus)
+
+	resp := &rfc9457.Response{Type: rfc9457.InternalServerErrorType, Status: 0}
+	rec := httptest.NewRecorder()
+	err := resp.Write(rec)
+
+	var writeErr *rfc9457.WriteError
+	if !errors.As(err, &writeErr) || !errors.Is(err, rfc9457.ErrInvalidStatus) {
+		t.Fatalf("Write: got %v, want *WriteError wrapping ErrInvalidStatus", err)
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Errorf("Write wrote output despite rejecting: %q", rec.Body.String())
+	}
+}
+
+func TestResponse_WriteAfterCommit(t *testing.T) {
+	resp := &rfc9457.Response{
+		Type:   rfc9457.NoResultsErrorType,
+		Title:  "No Results",
+		Status: 404,
+	}
+	rec := httptest.NewRecorder()
+	w := rfc9457.NewTrackingResponseWriter(rec)
+
+	if err := resp.Write(w); err != nil {
+		t.Fatalf("First Write: %v", err)
+	}
+	if !w.Committed() || w.Status() != 404 {
+		t.Errorf("Committed: got %v/%d, want true/404", w.Committed(), w.Status())
+	}
+	bodyLen := rec.Body.Len()
+
+	err := resp.Write(w)
+	var writeErr *rfc9457.WriteError
+	if !errors.As(err, &writeErr) || !errors.Is(err, rfc9457.ErrResponseCommitted) {
+		t.Fatalf("Second Write: got %v, want *WriteError wrapping ErrResponseCommitted", err)
+	}
+	if writeErr.CommittedStatus != 404 {
+		t.Errorf("CommittedStatus: got %d, want 404", writeErr.CommittedStatus)
+	}
+	if rec.Body.Len() != bodyLen {
+		t.Errorf("Second Write appended to body")
+	}
+
+	// Wrapping an already-tracking writer reuses it
+	if rfc9457.NewTrackingResponseWriter(w) != w {
+		t.Errorf("NewTrackingResponseWriter re-wrapped a tracking writer")
+	}
+}
@@ -0,0 +1,210 @@
+package rfc9457
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type TimeoutHandlerArgs struct {
+	// Handler is the handler being limited.
+	Handler http.Handler
+	// Timeout is how long Handler may run.
+	Timeout time.Duration
+	// Status is http.StatusServiceUnavailable, the default, or
+	// http.StatusGatewayTimeout.
+	Status int
+	// RetryAfter, when positive, is sent as a Retry-After header, rounded up
+	// to whole seconds.
+	RetryAfter time.Duration
+	// Type defaults to TimeoutErrorType and Detail to "The request did not
+	// complete within <Timeout>". Detail is not used when the client
+	// cancels the request first; the problem then says so instead.
+	Type   ErrorTypeURI
+	Detail string
+}
+
+// canceledDetail is the Detail of the problem written when the client
+// cancels the request before Handler returns.
+const canceledDetail = "The request was canceled before it completed"
+
+// TimeoutHandler is a replacement for http.TimeoutHandler that responds
+// with a problem instead of a fixed body. It runs Handler with a request
+// context that is canceled after Timeout, buffering its response. If
+// Handler has not returned by then, or the client goes away first, the
+// problem is written and anything Handler writes later is discarded, its
+// Write calls failing with http.ErrHandlerTimeout. The problem includes a
+// RetryableExtension, so clients may retry.
+//
+// As with http.TimeoutHandler, Handler's writer does not support flushing
+// or hijacking. A panic in Handler before the timeout is propagated with the
+// stack of the panicking goroutine appended to its value, except that
+// http.ErrAbortHandler is propagated as is; one after the timeout, when
+// there is no longer a caller to propagate it to, is logged through
+// HandlerComponent.
+type TimeoutHandler struct {
+	args TimeoutHandlerArgs
+}
+
+var _ http.Handler = (*TimeoutHandler)(nil)
+
+func NewTimeoutHandler(args TimeoutHandlerArgs) *TimeoutHandler {
+	if args.Status == 0 {
+		args.Status = http.StatusServiceUnavailable
+	}
+	if args.Type == "" {
+		args.Type = TimeoutErrorType
+	}
+	if args.Detail == "" {
+		args.Detail = fmt.Sprintf("The request did not complete within %s", args.Timeout)
+	}
+	return &TimeoutHandler{args: args}
+}
+
+func (h *TimeoutHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), h.args.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	tw := &timeoutWriter{header: make(http.Header)}
+	done := make(chan struct{})
+	panicked := make(chan handlerPanic, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				handlerPanicked(tw, req, handlerPanic{value: p, stack: debug.Stack()}, panicked)
+			}
+		}()
+		h.args.Handler.ServeHTTP(tw, req)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicked:
+		if p.value == http.ErrAbortHandler {
+			panic(p.value)
+		}
+		panic(fmt.Sprint(p.value, "\n\ngoroutine stack:\n", string(p.stack)))
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		maps.Copy(w.Header(), tw.header)
+		if tw.status == 0 {
+			tw.status = http.StatusOK
+		}
+		w.WriteHeader(tw.status)
+		_, _ = w.Write(tw.body.Bytes())
+	case <-ctx.Done():
+		tw.mu.Lock()
+		tw.timedOut = true
+		tw.mu.Unlock()
+		select {
+		case p := <-panicked:
+			logLatePanic(req, p)
+		default:
+		}
+		h.writeProblem(w, req, ctx.Err())
+	}
+}
+
+type handlerPanic struct {
+	value any
+	stack []byte
+}
+
+// handlerPanicked passes p to ServeHTTP, or logs it if the handler has
+// already timed out. p is sent while holding tw.mu, so a ServeHTTP that
+// times out afterwards finds it when it drains panicked.
+func handlerPanicked(tw *timeoutWriter, req *http.Request, p handlerPanic, panicked chan<- handlerPanic) {
+	tw.mu.Lock()
+	if !tw.timedOut {
+		panicked <- p
+		tw.mu.Unlock()
+		return
+	}
+	tw.mu.Unlock()
+	logLatePanic(req, p)
+}
+
+func logLatePanic(req *http.Request, p handlerPanic) {
+	if p.value == http.ErrAbortHandler {
+		return
+	}
+	LoggerFrom(req.Context(), HandlerComponent).Error("Handler panicked after timeout",
+		"panic", p.value,
+		"method", req.Method,
+		"url", req.URL.String(),
+		"stack", string(p.stack),
+	)
+}
+
+func (h *TimeoutHandler) writeProblem(w http.ResponseWriter, req *http.Request, cause error) {
+	if h.args.RetryAfter > 0 {
+		seconds := int64((h.args.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	detail := h.args.Detail
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded):
+		cause = fmt.Errorf("%w: %w", http.ErrHandlerTimeout, cause)
+	case errors.Is(cause, context.Canceled):
+		detail = canceledDetail
+	}
+	r := NewResponse(ResponseArgs{
+		Type:       h.args.Type,
+		Title:      http.StatusText(h.args.Status),
+		Status:     h.args.Status,
+		Detail:     detail,
+		Extensions: []Extension{RetryableExtension{Retryable: true}},
+		Request:    req,
+		Cause:      cause,
+	})
+	err := r.Write(w)
+	if err != nil {
+		LoggerFrom(req.Context(), HandlerComponent).Error("Failed to write timeout problem",
+			"error", err,
+		)
+	}
+}
+
+// timeoutWriter buffers a handler's response until TimeoutHandler either
+// copies it out or times out, after which writes fail.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
@@ -0,0 +1,242 @@
+package rfc9457
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var ErrUnknownTranslationFormat = errors.New("unknown translation file format")
+
+// TranslationUnit is one translatable message as exchanged with translators.
+// Source is the default-language text and Target its translation, empty in
+// templates.
+type TranslationUnit struct {
+	Key    MessageKey
+	Source string
+	Target string
+	// Fuzzy marks a translation flagged for review (PO "fuzzy" flag, XLIFF
+	// needs-review state); fuzzy units are not loaded into catalogs.
+	Fuzzy bool
+}
+
+// TranslationFile is the content of a PO or XLIFF file.
+type TranslationFile struct {
+	// Language is the target language; empty for templates.
+	Language string
+	Units    []TranslationUnit
+}
+
+// TranslationFormat identifies a translation file format.
+type TranslationFormat string
+
+const (
+	POFormat    TranslationFormat = "po"
+	XLIFFFormat TranslationFormat = "xliff"
+)
+
+// TranslationFormatFor infers the format from a file name's extension.
+func TranslationFormatFor(name string) (TranslationFormat, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".po", ".pot":
+		return POFormat, nil
+	case ".xlf", ".xliff":
+		return XLIFFFormat, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownTranslationFormat, name)
+}
+
+// ReadTranslationFile reads r in the given format.
+func ReadTranslationFile(r io.Reader, format TranslationFormat) (TranslationFile, error) {
+	switch format {
+	case POFormat:
+		return ReadPO(r)
+	case XLIFFFormat:
+		return ReadXLIFF(r)
+	}
+	return TranslationFile{}, fmt.Errorf("%w: %s", ErrUnknownTranslationFormat, format)
+}
+
+// WriteTranslationFile writes tf to w in the given format.
+func WriteTranslationFile(w io.Writer, tf TranslationFile, format TranslationFormat) error {
+	switch format {
+	case POFormat:
+		return WritePO(w, tf)
+	case XLIFFFormat:
+		return WriteXLIFF(w, tf)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownTranslationFormat, format)
+}
+
+// translationUnitID encodes a MessageKey as the single string PO msgctxt and
+// XLIFF trans-unit ids need. '|' cannot appear unescaped in a URI.
+func translationUnitID(key MessageKey) string {
+	return string(key.Type) + "|" + key.MessageID
+}
+
+func parseTranslationUnitID(id string) (key MessageKey, err error) {
+	typ, msgID, ok := strings.Cut(id, "|")
+	if !ok || typ == "" || msgID == "" {
+		err = fmt.Errorf("invalid translation unit id %q; expected '<type>|<message-id>'", id)
+		goto end
+	}
+	key = MessageKey{Type: ErrorTypeURI(typ), MessageID: msgID}
+end:
+	return key, err
+}
+
+// TemplateUnits returns a template unit for every registered error type's
+// canonical title plus every message c holds in its default language. c may
+// be nil.
+func TemplateUnits(c *MessageCatalog) []TranslationUnit {
+	units := make(map[MessageKey]TranslationUnit)
+	for _, def := range RegisteredErrorTypes() {
+		key := MessageKey{Type: def.Type, MessageID: TitleMessageID}
+		units[key] = TranslationUnit{Key: key, Source: def.Title}
+	}
+	if c != nil {
+		c.mu.RLock()
+		for key, msg := range c.messages[c.defaultLanguage] {
+			units[key] = TranslationUnit{Key: key, Source: msg}
+		}
+		c.mu.RUnlock()
+	}
+	return SortTranslationUnits(slices.Collect(maps.Values(units)))
+}
+
+// SortTranslationUnits sorts units by key, in place, and returns them.
+func SortTranslationUnits(units []TranslationUnit) []TranslationUnit {
+	slices.SortFunc(units, func(a, b TranslationUnit) int {
+		return compareMessageKeys(a.Key, b.Key)
+	})
+	return units
+}
+
+// AddTranslations loads the non-empty, non-fuzzy targets of tf into c under
+// tf.Language.
+func (c *MessageCatalog) AddTranslations(tf TranslationFile) error {
+	if tf.Language == "" {
+		return fmt.Errorf("translation file has no target language")
+	}
+	for _, unit := range tf.Units {
+		if unit.Target == "" || unit.Fuzzy {
+			continue
+		}
+		c.AddMessage(tf.Language, unit.Key, unit.Target)
+	}
+	return nil
+}
+
+// LoadCatalogFS reads every PO and XLIFF file in fsys matching patterns (see
+// fs.Glob) into c, typically from an embed.FS. Files without a declared
+// language take it from their base name, e.g. "locales/de.po".
+func LoadCatalogFS(c *MessageCatalog, fsys fs.FS, patterns ...string) (err error) {
+	var names []string
+	for _, pattern := range patterns {
+		var matches []string
+		matches, err = fs.Glob(fsys, pattern)
+		if err != nil {
+			goto end
+		}
+		names = append(names, matches...)
+	}
+	for _, name := range names {
+		err = loadCatalogFile(c, fsys, name)
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+func loadCatalogFile(c *MessageCatalog, fsys fs.FS, name string) (err error) {
+	var format TranslationFormat
+	var f fs.File
+	var tf TranslationFile
+
+	format, err = TranslationFormatFor(name)
+	if err != nil {
+		goto end
+	}
+	f, err = fsys.Open(name)
+	if err != nil {
+		goto end
+	}
+	defer func() { _ = f.Close() }()
+
+	tf, err = ReadTranslationFile(f, format)
+	if err != nil {
+		err = fmt.Errorf("reading %s: %w", name, err)
+		goto end
+	}
+	if tf.Language == "" {
+		tf.Language = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+	err = c.AddTranslations(tf)
+	if err != nil {
+		err = fmt.Errorf("loading %s: %w", name, err)
+	}
+end:
+	return err
+}
+
+// TranslationIssueKind classifies a TranslationIssue.
+type TranslationIssueKind string
+
+const (
+	// MissingTranslation means a template unit has no (or an empty) target.
+	MissingTranslation TranslationIssueKind = "missing"
+	// StaleTranslation means the translation's source no longer matches the
+	// template, the unit is no longer in the template, or it is fuzzy.
+	StaleTranslation TranslationIssueKind = "stale"
+)
+
+type TranslationIssue struct {
+	Kind   TranslationIssueKind
+	Key    MessageKey
+	Reason string
+}
+
+func (i TranslationIssue) String() string {
+	return fmt.Sprintf("%s: %s [%s]: %s", i.Kind, i.Key.Type, i.Key.MessageID, i.Reason)
+}
+
+// LintTranslations compares a translation against its template and reports
+// missing and stale units, sorted by key.
+func LintTranslations(template, translation TranslationFile) (issues []TranslationIssue) {
+	translated := make(map[MessageKey]TranslationUnit, len(translation.Units))
+	for _, unit := range translation.Units {
+		translated[unit.Key] = unit
+	}
+	inTemplate := make(map[MessageKey]bool, len(template.Units))
+	for _, want := range template.Units {
+		inTemplate[want.Key] = true
+		got, ok := translated[want.Key]
+		switch {
+		case !ok:
+			issues = append(issues, TranslationIssue{MissingTranslation, want.Key, "not in translation"})
+		case got.Target == "":
+			issues = append(issues, TranslationIssue{MissingTranslation, want.Key, "not translated"})
+		case got.Source != want.Source:
+			issues = append(issues, TranslationIssue{StaleTranslation, want.Key,
+				fmt.Sprintf("source changed from %q to %q", got.Source, want.Source)})
+		case got.Fuzzy:
+			issues = append(issues, TranslationIssue{StaleTranslation, want.Key, "marked for review"})
+		}
+	}
+	for _, unit := range translation.Units {
+		if !inTemplate[unit.Key] {
+			issues = append(issues, TranslationIssue{StaleTranslation, unit.Key, "no longer in template"})
+		}
+	}
+	slices.SortStableFunc(issues, func(a, b TranslationIssue) int {
+		return compareMessageKeys(a.Key, b.Key)
+	})
+	return issues
+}
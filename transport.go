@@ -0,0 +1,97 @@
+package rfc9457
+
+import (
+	"context"
+	"net/http"
+)
+
+// Transport is an http.RoundTripper that turns 4xx and 5xx replies into
+// *Response errors, so client code can use errors.As and switch on Type
+// rather than inspecting status codes. Problems with a registered domain
+// error (see RegisterProblemError) are returned as a *MappedError instead.
+// The original reply remains available from Response.HTTPResponse.
+//
+// 1xx, 2xx and 3xx replies pass through unchanged; 3xx must, so that
+// http.Client can follow redirects.
+//
+// Returning an error for a reply departs from the usual RoundTripper
+// contract; use WithoutProblemErrors on a request's context to get the
+// reply back instead.
+type Transport struct {
+	// Base performs the request; http.DefaultTransport when nil.
+	Base http.RoundTripper
+	// MaxBodySize bounds how much of an error body is read;
+	// DefaultMaxProblemBodySize when zero.
+	MaxBodySize int64
+	// Errors maps problems to domain errors; the registry used by
+	// RegisterProblemError when nil.
+	Errors *ProblemErrorRegistry
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+type problemErrorsKey struct{}
+
+// WithoutProblemErrors returns a context that makes Transport return error
+// replies as ordinary responses for requests using it.
+func WithoutProblemErrors(ctx context.Context) context.Context {
+	return context.WithValue(ctx, problemErrorsKey{}, false)
+}
+
+func problemErrorsEnabled(ctx context.Context) bool {
+	enabled, ok := ctx.Value(problemErrorsKey{}).(bool)
+	return !ok || enabled
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	var problem *Response
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err = base.RoundTrip(req)
+	if err != nil {
+		goto end
+	}
+	if resp.StatusCode < 400 || !problemErrorsEnabled(req.Context()) {
+		goto end
+	}
+
+	problem, err = t.parse(resp)
+	if err != nil {
+		// The reply was an error but its body could not be decoded; still
+		// surface a problem so callers need only one error path
+		LoggerFrom(req.Context(), ClientComponent).Warn("Failed to parse problem response",
+			"url", req.URL.String(),
+			"status", resp.StatusCode,
+			"error", err,
+		)
+		problem = synthesizeProblem(resp.StatusCode, "", nil)
+		problem.Detail = err.Error()
+		problem.httpResponse = resp
+	}
+	resp = nil
+	err = t.errors().Map(problem)
+end:
+	return resp, err
+}
+
+func (t *Transport) parse(resp *http.Response) (*Response, error) {
+	maxBodySize := t.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxProblemBodySize
+	}
+	return ParseHTTPResponseLimited(resp, maxBodySize)
+}
+
+func (t *Transport) errors() *ProblemErrorRegistry {
+	if t.Errors == nil {
+		return defaultProblemErrors
+	}
+	return t.Errors
+}
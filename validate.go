@@ -0,0 +1,110 @@
+package rfc9457
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Violation is one way in which a problem document breaks RFC 9457 or
+// disagrees with the registered error types.
+type Violation struct {
+	// Member is the problem member concerned, or empty for the document.
+	Member  string
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Member == "" {
+		return v.Message
+	}
+	return fmt.Sprintf("%s: %s", v.Member, v.Message)
+}
+
+// Validate checks a decoded problem. Type and Instance must be URI
+// references; Status, when present, must be an error status; a registered Type must carry
+// its registered Status; and an about:blank problem's Title should be the
+// HTTP reason phrase for its Status.
+func Validate(r *Response) (violations []Violation) {
+	add := func(member, format string, args ...any) {
+		violations = append(violations, Violation{Member: member, Message: fmt.Sprintf(format, args...)})
+	}
+	if r.Type != "" {
+		if _, err := url.Parse(string(r.Type)); err != nil {
+			add("type", "not a URI reference: %v", err)
+		}
+	}
+	if r.Instance != "" {
+		if _, err := url.Parse(r.Instance); err != nil {
+			add("instance", "not a URI reference: %v", err)
+		}
+	}
+	if r.Status != 0 && !IsProblemStatus(r.Status) {
+		add("status", "%d is not a 4xx or 5xx status code", r.Status)
+	}
+	if def, ok := LookupErrorType(r.Type); ok && def.Status != 0 && r.Status != 0 && def.Status != r.Status {
+		add("status", "%d does not match status %d registered for %s", r.Status, def.Status, r.Type)
+	}
+	if r.Type == "" || r.Type == AboutBlankErrorType {
+		phrase := http.StatusText(r.Status)
+		if phrase != "" && r.Title != "" && r.Title != phrase {
+			add("title", "%q should be %q for about:blank", r.Title, phrase)
+		}
+	}
+	return violations
+}
+
+// ValidateJSON decodes a problem+json document and validates it, first
+// checking the member types that decoding would otherwise silently coerce or
+// reject. Other top-level members are extension members (RFC 9457 §3.2) and
+// are not violations, although Response does not preserve them. The decoded
+// problem is returned when decoding succeeds.
+func ValidateJSON(data []byte) (r *Response, violations []Violation, err error) {
+	var members map[string]json.RawMessage
+
+	err = json.Unmarshal(data, &members)
+	if err != nil {
+		err = fmt.Errorf("problem document is not a JSON object: %w", err)
+		goto end
+	}
+	for _, name := range []string{"type", "title", "detail", "instance"} {
+		if raw, ok := members[name]; ok && !isJSONKind(raw, '"') {
+			violations = append(violations, Violation{Member: name, Message: "must be a string"})
+			delete(members, name)
+		}
+	}
+	if raw, ok := members["status"]; ok {
+		var status int
+		if json.Unmarshal(raw, &status) != nil {
+			violations = append(violations, Violation{Member: "status", Message: "must be an integer"})
+			delete(members, "status")
+		}
+	}
+	if raw, ok := members["extensions"]; ok && !isJSONKind(raw, '[') {
+		violations = append(violations, Violation{Member: "extensions", Message: "must be an array"})
+		delete(members, "extensions")
+	}
+	if len(violations) > 0 {
+		data, err = json.Marshal(members)
+		if err != nil {
+			goto end
+		}
+	}
+
+	r = &Response{}
+	err = r.UnmarshalJSON(data)
+	if err != nil {
+		r = nil
+		goto end
+	}
+	violations = append(violations, Validate(r)...)
+end:
+	return r, violations, err
+}
+
+func isJSONKind(raw json.RawMessage, first byte) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == first
+}
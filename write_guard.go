@@ -0,0 +1,173 @@
+package rfc9457
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("invalid problem status")
+	ErrResponseCommitted = errors.New("response already committed")
+)
+
+// WriteError reports why Response.Write did not write. Err is
+// ErrInvalidStatus or ErrResponseCommitted, so callers can use errors.Is.
+type WriteError struct {
+	Err    error
+	Status int
+	// CommittedStatus is the status already sent when Err is
+	// ErrResponseCommitted.
+	CommittedStatus int
+}
+
+func (e *WriteError) Error() string {
+	if errors.Is(e.Err, ErrResponseCommitted) {
+		return fmt.Sprintf("%v: cannot write problem with status %d after status %d was sent",
+			e.Err, e.Status, e.CommittedStatus)
+	}
+	return fmt.Sprintf("%v: %d is not a 4xx or 5xx status", e.Err, e.Status)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// StatusPolicy decides what Response.Write does with a status outside
+// 400–599.
+type StatusPolicy int
+
+const (
+	// NormalizeInvalidStatus writes the problem with status 500 and logs a
+	// warning. This is the default.
+	NormalizeInvalidStatus StatusPolicy = iota
+	// RejectInvalidStatus writes nothing and returns a *WriteError.
+	RejectInvalidStatus
+)
+
+var statusPolicy atomic.Int32
+
+// SetStatusPolicy sets how Response.Write handles invalid statuses.
+func SetStatusPolicy(p StatusPolicy) {
+	statusPolicy.Store(int32(p))
+}
+
+func GetStatusPolicy() StatusPolicy {
+	return StatusPolicy(statusPolicy.Load())
+}
+
+// IsProblemStatus reports whether status is a client or server error, the
+// only statuses a problem document can meaningfully carry.
+func IsProblemStatus(status int) bool {
+	return status >= 400 && status <= 599
+}
+
+// CommitTracker is implemented by ResponseWriters that know whether the
+// status line has been sent, such as TrackingResponseWriter.
+type CommitTracker interface {
+	Committed() bool
+	Status() int
+}
+
+// TrackingResponseWriter wraps an http.ResponseWriter to record whether and
+// with what status the response was committed. Extra WriteHeader calls are
+// dropped rather than passed on, avoiding net/http's superfluous-WriteHeader
+// log noise.
+type TrackingResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+var _ CommitTracker = (*TrackingResponseWriter)(nil)
+
+// NewTrackingResponseWriter wraps w, or returns w itself if it is already a
+// *TrackingResponseWriter.
+func NewTrackingResponseWriter(w http.ResponseWriter) *TrackingResponseWriter {
+	tw, ok := w.(*TrackingResponseWriter)
+	if !ok {
+		tw = &TrackingResponseWriter{ResponseWriter: w}
+	}
+	return tw
+}
+
+func (w *TrackingResponseWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	// 1xx informational responses do not commit the final status
+	if status >= 100 && status <= 199 && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *TrackingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Committed reports whether the status line has been sent.
+func (w *TrackingResponseWriter) Committed() bool {
+	return w.status != 0
+}
+
+// Status returns the committed status, or 0 if not yet committed.
+func (w *TrackingResponseWriter) Status() int {
+	return w.status
+}
+
+// BytesWritten returns the number of body bytes written.
+func (w *TrackingResponseWriter) BytesWritten() int64 {
+	return w.written
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *TrackingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *TrackingResponseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *TrackingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// checkWritable validates the status of r against w and the status policy,
+// returning the status to send.
+func (r *Response) checkWritable(w http.ResponseWriter) (status int, err error) {
+	status = r.Status
+	if ct, ok := w.(CommitTracker); ok && ct.Committed() {
+		err = &WriteError{Err: ErrResponseCommitted, Status: r.Status, CommittedStatus: ct.Status()}
+		goto end
+	}
+	if IsProblemStatus(status) {
+		goto end
+	}
+	if GetStatusPolicy() == RejectInvalidStatus {
+		err = &WriteError{Err: ErrInvalidStatus, Status: r.Status}
+		goto end
+	}
+	status = http.StatusInternalServerError
+	r.logger(WriteComponent).Warn("Normalized invalid problem status",
+		"type", r.Type,
+		"status", r.Status,
+		"normalized_status", status,
+	)
+end:
+	return status, err
+}
@@ -0,0 +1,122 @@
+package rfc9457
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// XLIFF 1.2 is used as it is the version most translation tools accept.
+const xliffNamespace = "urn:oasis:names:tc:xliff:document:1.2"
+
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string      `xml:"version,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr,omitempty"`
+	Datatype       string      `xml:"datatype,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+type xliffUnit struct {
+	ID     string       `xml:"id,attr"`
+	Source string       `xml:"source"`
+	Target *xliffTarget `xml:"target,omitempty"`
+}
+
+type xliffTarget struct {
+	State string `xml:"state,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+// XLIFF target states that mean the translation should not be used yet.
+var xliffReviewStates = map[string]bool{
+	"new":                      true,
+	"needs-translation":        true,
+	"needs-adaptation":         true,
+	"needs-l10n":               true,
+	"needs-review-translation": true,
+	"needs-review-adaptation":  true,
+	"needs-review-l10n":        true,
+}
+
+// WriteXLIFF writes tf as an XLIFF 1.2 document. Each unit's MessageKey is
+// carried in its trans-unit id.
+func WriteXLIFF(w io.Writer, tf TranslationFile) (err error) {
+	file := xliffFile{
+		Original:       "rfc9457",
+		SourceLanguage: DefaultLanguage,
+		TargetLanguage: tf.Language,
+		Datatype:       "plaintext",
+		Units:          make([]xliffUnit, len(tf.Units)),
+	}
+	for i, unit := range tf.Units {
+		file.Units[i] = xliffUnit{
+			ID:     translationUnitID(unit.Key),
+			Source: unit.Source,
+		}
+		if tf.Language == "" {
+			continue
+		}
+		target := &xliffTarget{Text: unit.Target, State: "translated"}
+		switch {
+		case unit.Target == "":
+			target.State = "needs-translation"
+		case unit.Fuzzy:
+			target.State = "needs-review-translation"
+		}
+		file.Units[i].Target = target
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		goto end
+	}
+	{
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		err = enc.Encode(xliffDocument{Version: "1.2", Files: []xliffFile{file}})
+		if err != nil {
+			goto end
+		}
+	}
+	_, err = io.WriteString(w, "\n")
+end:
+	return err
+}
+
+// ReadXLIFF parses an XLIFF 1.2 document. Units from all <file> elements are
+// returned; the language is taken from the first file's target-language.
+func ReadXLIFF(r io.Reader) (tf TranslationFile, err error) {
+	var doc xliffDocument
+	err = xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		err = fmt.Errorf("decoding XLIFF (expected namespace %s): %w", xliffNamespace, err)
+		goto end
+	}
+	for _, file := range doc.Files {
+		if tf.Language == "" {
+			tf.Language = file.TargetLanguage
+		}
+		for _, xu := range file.Units {
+			var key MessageKey
+			key, err = parseTranslationUnitID(xu.ID)
+			if err != nil {
+				goto end
+			}
+			unit := TranslationUnit{Key: key, Source: xu.Source}
+			if xu.Target != nil {
+				unit.Target = xu.Target.Text
+				unit.Fuzzy = xliffReviewStates[xu.Target.State]
+			}
+			tf.Units = append(tf.Units, unit)
+		}
+	}
+end:
+	return tf, err
+}
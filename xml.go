@@ -0,0 +1,218 @@
+package rfc9457
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ProblemXMLNamespace is the namespace of the XML problem format defined in
+// RFC 9457 Appendix B.
+const ProblemXMLNamespace = "urn:ietf:rfc:7807"
+
+// MarshalProblemXML encodes r in the application/problem+xml format. Members
+// are written as child elements of <problem>; arrays, including
+// "extensions", use <i> elements per RFC 9457 Appendix B.
+func MarshalProblemXML(r *Response) (data []byte, err error) {
+	var raw []byte
+	var doc map[string]any
+	var buf bytes.Buffer
+	var enc *xml.Encoder
+
+	raw, err = json.Marshal(r)
+	if err != nil {
+		goto end
+	}
+	err = json.Unmarshal(raw, &doc)
+	if err != nil {
+		goto end
+	}
+	buf.WriteString(xml.Header)
+	enc = xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	err = encodeXMLValue(enc, xml.Name{Space: ProblemXMLNamespace, Local: "problem"}, doc)
+	if err != nil {
+		goto end
+	}
+	err = enc.Close()
+	if err != nil {
+		goto end
+	}
+	buf.WriteByte('\n')
+	data = buf.Bytes()
+end:
+	return data, err
+}
+
+// problemMemberOrder keeps the standard members first, in RFC order.
+var problemMemberOrder = []string{"type", "title", "status", "detail", "instance"}
+
+func encodeXMLValue(enc *xml.Encoder, name xml.Name, value any) (err error) {
+	start := xml.StartElement{Name: name}
+	err = enc.EncodeToken(start)
+	if err != nil {
+		goto end
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range sortedMemberNames(v) {
+			err = encodeXMLValue(enc, xml.Name{Local: key}, v[key])
+			if err != nil {
+				goto end
+			}
+		}
+	case []any:
+		for _, item := range v {
+			err = encodeXMLValue(enc, xml.Name{Local: "i"}, item)
+			if err != nil {
+				goto end
+			}
+		}
+	case nil:
+	default:
+		err = enc.EncodeToken(xml.CharData(fmt.Sprint(v)))
+		if err != nil {
+			goto end
+		}
+	}
+	err = enc.EncodeToken(start.End())
+end:
+	return err
+}
+
+func sortedMemberNames(m map[string]any) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		ai, bi := slices.Index(problemMemberOrder, a), slices.Index(problemMemberOrder, b)
+		switch {
+		case ai >= 0 && bi >= 0:
+			return ai - bi
+		case ai >= 0:
+			return -1
+		case bi >= 0:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return names
+}
+
+// UnmarshalProblemXML decodes an application/problem+xml document into r by
+// converting it to JSON and calling Response.UnmarshalJSON. XML carries no
+// types, so every member other than status decodes as a string, and an
+// element containing only <i> children decodes as an array.
+func UnmarshalProblemXML(data []byte, r *Response) (err error) {
+	var doc any
+	var raw []byte
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		var tok xml.Token
+		tok, err = dec.Token()
+		if err != nil {
+			err = fmt.Errorf("decoding problem XML: %w", err)
+			goto end
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "problem" {
+			err = fmt.Errorf("decoding problem XML: root element is <%s>, not <problem>", start.Name.Local)
+			goto end
+		}
+		doc, err = decodeXMLValue(dec, 0)
+		if err != nil {
+			err = fmt.Errorf("decoding problem XML: %w", err)
+			goto end
+		}
+		break
+	}
+	if m, ok := doc.(map[string]any); ok {
+		if s, ok := m["status"].(string); ok {
+			status, convErr := strconv.Atoi(strings.TrimSpace(s))
+			if convErr == nil {
+				m["status"] = status
+			}
+		}
+		if ext, ok := m["extensions"]; ok {
+			if _, isArray := ext.([]any); !isArray {
+				m["extensions"] = []any{ext}
+			}
+		}
+	} else {
+		doc = map[string]any{}
+	}
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		goto end
+	}
+	err = r.UnmarshalJSON(raw)
+end:
+	return err
+}
+
+// maxXMLDepth bounds nesting so hostile documents cannot exhaust the stack.
+const maxXMLDepth = 64
+
+// decodeXMLValue decodes the content of the element whose start token was
+// just read.
+func decodeXMLValue(dec *xml.Decoder, depth int) (value any, err error) {
+	var text strings.Builder
+	var members map[string]any
+	var items []any
+	var onlyItems = true
+
+	if depth > maxXMLDepth {
+		err = fmt.Errorf("nesting exceeds %d levels", maxXMLDepth)
+		goto end
+	}
+	for {
+		var tok xml.Token
+		tok, err = dec.Token()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			goto end
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			var child any
+			child, err = decodeXMLValue(dec, depth+1)
+			if err != nil {
+				goto end
+			}
+			if members == nil {
+				members = make(map[string]any)
+			}
+			if t.Name.Local != "i" {
+				onlyItems = false
+			}
+			items = append(items, child)
+			members[t.Name.Local] = child
+		case xml.EndElement:
+			switch {
+			case members == nil:
+				value = strings.TrimSpace(text.String())
+			case onlyItems:
+				value = items
+			default:
+				value = members
+			}
+			goto end
+		}
+	}
+end:
+	return value, err
+}